@@ -0,0 +1,432 @@
+// Package asm translates between bytecode modules and a textual assembly
+// syntax, conventionally stored in .sla files.
+//
+// An assembly file holds one directive, label or instruction per line.
+// Comments run from # to the end of the line.
+//
+//	# Pushes two copies of S(Z).
+//	package foo
+//
+//	symbol Z
+//	symbol S
+//
+//		call main
+//		jump 8
+//	main:
+//		push S
+//		push Z
+//		group 2
+//		dup
+//		return
+//
+// The package directive sets the module's package, and each symbol directive
+// adds a symbol to its symbol table, in order. Symbols are pushed by name, or
+// by index. A name declared more than once refers to its first declaration.
+//
+// A line of the form "name:" assembles to a Label operation. Jump, branch,
+// call and choice take a target which is either the name of a label defined
+// once or the position of an operation in the code, counting from 0.
+//
+// Each operation is written as its name in lowercase followed by its
+// arguments, as in "permute 3 0 2 1" or "recall 0". These aliases stand for
+// common permutes:
+//
+//	dup     permute 1 0 0
+//	swap    permute 2 0 1
+//	pop     permute 1
+//	pop n   permute n
+//	roll n  permute n 0 n-1 ... 1, which moves the top of the stack n-1 down
+//
+// Names holding spaces, '#' or other characters which can't be written as
+// is, and names which could be read as numbers, are written quoted, as Go
+// strings are, like "my file.slm" or push "7".
+//
+// Debug info is written with two more directives. Each file directive adds a
+// source file to the module's debug info, in order, and a directive of the
+// form "pos file:line:column" gives the source position of the operations
+// after it, up to the next pos directive. "pos -" marks their positions as
+// unknown.
+//
+//	file foo.slm
+//
+//	pos foo.slm:3:1
+//	main:
+//	pos foo.slm:3:9
+//		push S
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hjfreyer/stalog/ast"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Error is an error in an assembly file.
+type Error struct {
+	Pos ast.Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Pos, e.Msg)
+}
+
+// ErrorList is a list of errors in an assembly file, in order.
+type ErrorList []*Error
+
+// Error returns the messages of the errors, one per line.
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// line is a line of assembly holding a label or an instruction.
+type line struct {
+	pos    ast.Pos
+	src    *pb.Position
+	fields []string
+}
+
+// Assemble assembles src, the assembly file named filename, to a module. If
+// src has errors, the error is an ErrorList.
+func Assemble(filename, src string) (*pb.Module, error) {
+	mod := &pb.Module{}
+	symbols := map[string]int32{}
+	labels := map[string]int32{}
+	files := map[string]int32{}
+	var errs ErrorList
+	var code []line
+	var at *pb.Position
+	for i, text := range strings.Split(src, "\n") {
+		pos := ast.Pos{Filename: filename, Line: i + 1, Column: 1}
+		fields, err := split(text)
+		if err != nil {
+			errs = append(errs, &Error{pos, err.Error()})
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		var name string
+		if len(fields) == 2 && (fields[0] == "package" || fields[0] == "symbol" || fields[0] == "file") {
+			if name, err = unquote(fields[1]); err != nil {
+				errs = append(errs, &Error{pos, err.Error()})
+				continue
+			}
+		}
+		switch {
+		case fields[0] == "package" && len(fields) == 2:
+			mod.Package = name
+		case fields[0] == "symbol" && len(fields) == 2:
+			if _, ok := symbols[name]; !ok {
+				symbols[name] = int32(len(mod.Symbols))
+			}
+			mod.Symbols = append(mod.Symbols, name)
+		case fields[0] == "file" && len(fields) == 2:
+			if mod.DebugInfo == nil {
+				mod.DebugInfo = &pb.DebugInfo{}
+			}
+			if _, ok := files[name]; !ok {
+				files[name] = int32(len(mod.DebugInfo.Files))
+			}
+			mod.DebugInfo.Files = append(mod.DebugInfo.Files, name)
+		case fields[0] == "pos" && len(fields) == 2:
+			p, err := position(fields[1], files)
+			if err != nil {
+				errs = append(errs, &Error{pos, err.Error()})
+			}
+			at = p
+		case len(fields) == 1 && strings.HasSuffix(fields[0], ":"):
+			// A bad name is reported when the label is parsed.
+			if name, err := unquote(strings.TrimSuffix(fields[0], ":")); err == nil {
+				if _, ok := labels[name]; ok {
+					labels[name] = -1
+				} else {
+					labels[name] = int32(len(code))
+				}
+			}
+			code = append(code, line{pos, at, fields})
+		default:
+			code = append(code, line{pos, at, fields})
+		}
+	}
+
+	symbol := func(name string) (int32, error) {
+		if idx, ok := symbols[name]; ok {
+			return idx, nil
+		}
+		return 0, fmt.Errorf("undeclared symbol %s", name)
+	}
+	label := func(name string) (int32, error) {
+		target, ok := labels[name]
+		if !ok {
+			return 0, fmt.Errorf("undefined label %s", name)
+		}
+		if target < 0 {
+			return 0, fmt.Errorf("label %s defined more than once", name)
+		}
+		return target, nil
+	}
+	for _, l := range code {
+		op, err := Parse(l.fields, symbol, label)
+		if err != nil {
+			errs = append(errs, &Error{l.pos, err.Error()})
+			continue
+		}
+		mod.Code = append(mod.Code, op)
+	}
+	if len(errs) != 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Pos.Line < errs[j].Pos.Line })
+		return nil, errs
+	}
+	if mod.DebugInfo != nil {
+		// Trailing unknown positions are left out.
+		n := len(code)
+		for 0 < n && code[n-1].src == nil {
+			n--
+		}
+		for _, l := range code[:n] {
+			p := l.src
+			if p == nil {
+				p = &pb.Position{}
+			}
+			mod.DebugInfo.Positions = append(mod.DebugInfo.Positions, p)
+		}
+	}
+	return mod, nil
+}
+
+// position parses the argument of a pos directive, looking up the file in
+// files. It returns nil for an unknown position.
+func position(arg string, files map[string]int32) (*pb.Position, error) {
+	if arg == "-" {
+		return nil, nil
+	}
+	parts := strings.Split(arg, ":")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("bad position %s", arg)
+	}
+	n := len(parts)
+	name, err := unquote(strings.Join(parts[:n-2], ":"))
+	if err != nil {
+		return nil, err
+	}
+	idx, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared file %s", name)
+	}
+	line, err := number(parts[n-2])
+	if err != nil {
+		return nil, err
+	}
+	col, err := number(parts[n-1])
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Position{FileIdx: idx, Line: line, Column: col}, nil
+}
+
+// Parse parses the fields of a single label or instruction. symbol and label
+// return the indices of symbols and the positions of labels given by name,
+// which may be quoted; numbers given instead are used as is.
+func Parse(fields []string, symbol, label func(name string) (int32, error)) (*pb.Operation, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing instruction")
+	}
+	name, args := fields[0], fields[1:]
+	if len(fields) == 1 && strings.HasSuffix(name, ":") {
+		label, err := unquote(strings.TrimSuffix(name, ":"))
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Operation{Op: &pb.Operation_Label{Label: &pb.Label{Name: label}}}, nil
+	}
+	nargs, ok := arities[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown instruction %s", name)
+	}
+	if name == "pop" && len(args) == 0 {
+		args = []string{"1"}
+	}
+	if nargs < 0 && len(args) == 0 || 0 <= nargs && len(args) != nargs {
+		return nil, fmt.Errorf("wrong number of arguments to %s", name)
+	}
+
+	var ints []int32
+	for _, arg := range args {
+		var n int32
+		var err error
+		switch name {
+		case "push":
+			n, err = resolve(arg, symbol)
+		case "jump", "branch", "call", "choice":
+			n, err = resolve(arg, label)
+		default:
+			n, err = number(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, n)
+	}
+
+	switch name {
+	case "push":
+		return &pb.Operation{Op: &pb.Operation_Push{Push: &pb.Push{SymbolIdx: ints[0]}}}, nil
+	case "permute":
+		return permute(ints[0], ints[1:]...), nil
+	case "dup":
+		return permute(1, 0, 0), nil
+	case "swap":
+		return permute(2, 0, 1), nil
+	case "pop":
+		return permute(ints[0]), nil
+	case "roll":
+		n := ints[0]
+		if n < 1 {
+			return nil, fmt.Errorf("can't roll %d values", n)
+		}
+		push := []int32{0}
+		for i := n - 1; 0 < i; i-- {
+			push = append(push, i)
+		}
+		return permute(n, push...), nil
+	case "commit":
+		return &pb.Operation{Op: &pb.Operation_Commit{Commit: &pb.Commit{}}}, nil
+	case "recall":
+		return &pb.Operation{Op: &pb.Operation_Recall{Recall: &pb.Recall{Index: ints[0]}}}, nil
+	case "group":
+		return &pb.Operation{Op: &pb.Operation_Group{Group: &pb.Group{Count: ints[0]}}}, nil
+	case "ungroup":
+		return &pb.Operation{Op: &pb.Operation_Ungroup{Ungroup: &pb.Ungroup{Count: ints[0]}}}, nil
+	case "jump":
+		return &pb.Operation{Op: &pb.Operation_Jump{Jump: &pb.Jump{Target: ints[0]}}}, nil
+	case "branch":
+		return &pb.Operation{Op: &pb.Operation_Branch{Branch: &pb.Branch{Target: ints[0]}}}, nil
+	case "call":
+		return &pb.Operation{Op: &pb.Operation_Call{Call: &pb.Call{Target: ints[0]}}}, nil
+	case "return":
+		return &pb.Operation{Op: &pb.Operation_Return{Return: &pb.Return{}}}, nil
+	case "choice":
+		return &pb.Operation{Op: &pb.Operation_Choice{Choice: &pb.Choice{Target: ints[0]}}}, nil
+	case "fail":
+		return &pb.Operation{Op: &pb.Operation_Fail{Fail: &pb.Fail{}}}, nil
+	case "fresh":
+		return &pb.Operation{Op: &pb.Operation_Fresh{Fresh: &pb.Fresh{}}}, nil
+	case "unify":
+		return &pb.Operation{Op: &pb.Operation_Unify{Unify: &pb.Unify{}}}, nil
+	}
+	panic("unhandled instruction " + name)
+}
+
+// arities maps the names of instructions to their numbers of arguments, or
+// -1 for instructions taking one or more.
+var arities = map[string]int{
+	"push":    1,
+	"permute": -1,
+	"dup":     0,
+	"swap":    0,
+	"pop":     1,
+	"roll":    1,
+	"commit":  0,
+	"recall":  1,
+	"group":   1,
+	"ungroup": 1,
+	"jump":    1,
+	"branch":  1,
+	"call":    1,
+	"return":  0,
+	"choice":  1,
+	"fail":    0,
+	"fresh":   0,
+	"unify":   0,
+}
+
+func permute(pop int32, push ...int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Permute{Permute: &pb.Permute{Pop: pop, Push: push}}}
+}
+
+// resolve returns the number arg, or the result of looking it up by name.
+func resolve(arg string, lookup func(string) (int32, error)) (int32, error) {
+	if strings.HasPrefix(arg, `"`) {
+		name, err := unquote(arg)
+		if err != nil {
+			return 0, err
+		}
+		return lookup(name)
+	}
+	if n, err := number(arg); err == nil {
+		return n, nil
+	}
+	return lookup(arg)
+}
+
+// split splits a line of assembly into fields, leaving out any comment.
+// Quoted names are kept in their fields with their quotes.
+func split(text string) ([]string, error) {
+	var fields []string
+	start := -1
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '#':
+			text = text[:i]
+			continue
+		case unicode.IsSpace(r):
+			if 0 <= start {
+				fields = append(fields, text[start:i])
+				start = -1
+			}
+		case start < 0:
+			start = i
+		}
+		if r == '"' {
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if len(text) <= end {
+				return nil, errors.New("unterminated name")
+			}
+			i = end
+		}
+		i += size
+	}
+	if 0 <= start {
+		fields = append(fields, text[start:])
+	}
+	return fields, nil
+}
+
+// unquote returns the name written as s, which may be quoted.
+func unquote(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+	name, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("bad name %s", s)
+	}
+	return name, nil
+}
+
+func number(arg string) (int32, error) {
+	n, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %s", arg)
+	}
+	return int32(n), nil
+}
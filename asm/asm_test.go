@@ -0,0 +1,309 @@
+package asm
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hjfreyer/stalog/compiler"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+func op(o interface{}) *pb.Operation {
+	switch o := o.(type) {
+	case *pb.Push:
+		return &pb.Operation{Op: &pb.Operation_Push{Push: o}}
+	case *pb.Permute:
+		return &pb.Operation{Op: &pb.Operation_Permute{Permute: o}}
+	case *pb.Label:
+		return &pb.Operation{Op: &pb.Operation_Label{Label: o}}
+	case *pb.Jump:
+		return &pb.Operation{Op: &pb.Operation_Jump{Jump: o}}
+	case *pb.Call:
+		return &pb.Operation{Op: &pb.Operation_Call{Call: o}}
+	case *pb.Choice:
+		return &pb.Operation{Op: &pb.Operation_Choice{Choice: o}}
+	case *pb.Group:
+		return &pb.Operation{Op: &pb.Operation_Group{Group: o}}
+	case *pb.Return:
+		return &pb.Operation{Op: &pb.Operation_Return{Return: o}}
+	}
+	panic(o)
+}
+
+func TestAssemble(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		want *pb.Module
+	}{{
+		name: "empty",
+		src:  "package foo\n",
+		want: &pb.Module{Package: "foo"},
+	}, {
+		name: "symbols",
+		src: `package foo
+symbol Z
+symbol S  # Successor.
+	push S
+	push Z
+	push 5
+	group 2
+`,
+		want: &pb.Module{
+			Package: "foo",
+			Symbols: []string{"Z", "S"},
+			Code: []*pb.Operation{
+				op(&pb.Push{SymbolIdx: 1}),
+				op(&pb.Push{SymbolIdx: 0}),
+				op(&pb.Push{SymbolIdx: 5}),
+				op(&pb.Group{Count: 2}),
+			},
+		},
+	}, {
+		name: "aliases",
+		src: `package foo
+	dup
+	swap
+	pop
+	pop 3
+	roll 3
+	roll 4
+	permute 2 1 1
+`,
+		want: &pb.Module{
+			Package: "foo",
+			Code: []*pb.Operation{
+				op(&pb.Permute{Pop: 1, Push: []int32{0, 0}}),
+				op(&pb.Permute{Pop: 2, Push: []int32{0, 1}}),
+				op(&pb.Permute{Pop: 1}),
+				op(&pb.Permute{Pop: 3}),
+				op(&pb.Permute{Pop: 3, Push: []int32{0, 2, 1}}),
+				op(&pb.Permute{Pop: 4, Push: []int32{0, 3, 2, 1}}),
+				op(&pb.Permute{Pop: 2, Push: []int32{1, 1}}),
+			},
+		},
+	}, {
+		name: "labels",
+		src: `package foo
+	call main
+	jump 5
+main:
+	choice nat:one
+nat:one:
+	return
+`,
+		want: &pb.Module{
+			Package: "foo",
+			Code: []*pb.Operation{
+				op(&pb.Call{Target: 2}),
+				op(&pb.Jump{Target: 5}),
+				op(&pb.Label{Name: "main"}),
+				op(&pb.Choice{Target: 4}),
+				op(&pb.Label{Name: "nat:one"}),
+				op(&pb.Return{}),
+			},
+		},
+	}, {
+		name: "debug info",
+		src: `package foo
+file foo.slm
+file lib/a:b.slm
+	return
+pos foo.slm:3:1
+main:
+pos -
+	return
+pos lib/a:b.slm:10:5
+	return
+pos -
+	return
+`,
+		want: &pb.Module{
+			Package: "foo",
+			Code: []*pb.Operation{
+				op(&pb.Return{}),
+				op(&pb.Label{Name: "main"}),
+				op(&pb.Return{}),
+				op(&pb.Return{}),
+				op(&pb.Return{}),
+			},
+			DebugInfo: &pb.DebugInfo{
+				Files: []string{"foo.slm", "lib/a:b.slm"},
+				Positions: []*pb.Position{
+					{},
+					{FileIdx: 0, Line: 3, Column: 1},
+					{},
+					{FileIdx: 1, Line: 10, Column: 5},
+				},
+			},
+		},
+	}}
+
+	for _, tc := range tcs {
+		got, err := Assemble("foo.sla", tc.src)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%s: wrong module. Got:\n%v; wanted:\n%v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	src := `package foo
+symbol Z
+	push S
+pos foo.slm:1:1
+	bogus 1
+	jump nowhere
+	group
+	permute x
+	roll 0
+a:
+a:
+	jump a
+file foo.slm
+pos foo.slm:x:1
+symbol "Z
+	push "\q"
+`
+	want := `foo.sla:3:1: undeclared symbol S
+foo.sla:4:1: undeclared file foo.slm
+foo.sla:5:1: unknown instruction bogus
+foo.sla:6:1: undefined label nowhere
+foo.sla:7:1: wrong number of arguments to group
+foo.sla:8:1: bad number x
+foo.sla:9:1: can't roll 0 values
+foo.sla:12:1: label a defined more than once
+foo.sla:14:1: bad number x
+foo.sla:15:1: unterminated name
+foo.sla:16:1: bad name "\q"`
+	_, err := Assemble("foo.sla", src)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	if err.Error() != want {
+		t.Errorf("wrong errors. Got:\n%v\nwanted:\n%s", err, want)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	mod := &pb.Module{
+		Package: "foo",
+		Symbols: []string{"Z", "S", "Z"},
+		Code: []*pb.Operation{
+			op(&pb.Call{Target: 2}),
+			op(&pb.Jump{Target: 8}),
+			op(&pb.Label{Name: "main"}),
+			op(&pb.Push{SymbolIdx: 1}),
+			op(&pb.Push{SymbolIdx: 2}),
+			op(&pb.Permute{Pop: 3, Push: []int32{0, 2, 1}}),
+			op(&pb.Permute{Pop: 2, Push: []int32{1, 0}}),
+			op(&pb.Return{}),
+		},
+		DebugInfo: &pb.DebugInfo{
+			Files: []string{"foo.slm"},
+			Positions: []*pb.Position{
+				{},
+				{},
+				{FileIdx: 0, Line: 1, Column: 1},
+				{FileIdx: 0, Line: 1, Column: 9},
+				{FileIdx: 0, Line: 1, Column: 9},
+				{FileIdx: 0, Line: 2, Column: 3},
+			},
+		},
+	}
+	want := `package foo
+
+symbol Z
+symbol S
+symbol Z
+
+file foo.slm
+
+	call main		# 0
+	jump 8			# 1
+pos foo.slm:1:1
+main:				# 2
+pos foo.slm:1:9
+	push S			# 3
+	push 2			# 4
+pos foo.slm:2:3
+	roll 3			# 5
+pos -
+	permute 2 1 0		# 6
+	return			# 7
+`
+	if got := Disassemble(mod); got != want {
+		t.Errorf("wrong disassembly. Got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src, err := ioutil.ReadFile("../examples/nat.slm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nat, err := compiler.Compile("nat.slm", string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dupLabels := &pb.Module{
+		Package: "foo",
+		Code: []*pb.Operation{
+			op(&pb.Label{Name: "a"}),
+			op(&pb.Label{Name: "a"}),
+			op(&pb.Label{Name: "7"}),
+			op(&pb.Jump{Target: 1}),
+			op(&pb.Jump{Target: 2}),
+		},
+	}
+
+	noPackage := &pb.Module{
+		Symbols: []string{"Z"},
+		Code:    []*pb.Operation{op(&pb.Push{SymbolIdx: 0})},
+	}
+
+	// Names which can't be written as is are quoted.
+	oddNames := &pb.Module{
+		Package: "my package",
+		Symbols: []string{"A B", "#", "7", "", `"`, "Z:"},
+		Code: []*pb.Operation{
+			op(&pb.Label{Name: "a b"}),
+			op(&pb.Label{Name: "#c"}),
+			op(&pb.Label{Name: "7"}),
+			op(&pb.Label{Name: "d:"}),
+			op(&pb.Push{SymbolIdx: 0}),
+			op(&pb.Push{SymbolIdx: 1}),
+			op(&pb.Push{SymbolIdx: 2}),
+			op(&pb.Push{SymbolIdx: 3}),
+			op(&pb.Push{SymbolIdx: 4}),
+			op(&pb.Push{SymbolIdx: 5}),
+			op(&pb.Jump{Target: 0}),
+			op(&pb.Jump{Target: 1}),
+			op(&pb.Jump{Target: 3}),
+		},
+		DebugInfo: &pb.DebugInfo{
+			Files: []string{"my file.slm", "a:b#c.slm"},
+			Positions: []*pb.Position{
+				{FileIdx: 0, Line: 1, Column: 1},
+				{FileIdx: 1, Line: 2, Column: 3},
+			},
+		},
+	}
+
+	for _, mod := range []*pb.Module{nat, dupLabels, noPackage, oddNames, {}} {
+		text := Disassemble(mod)
+		got, err := Assemble("", text)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", text, err)
+			continue
+		}
+		if !proto.Equal(got, mod) {
+			t.Errorf("round trip changed module. Got:\n%v; wanted:\n%v", got, mod)
+		}
+	}
+}
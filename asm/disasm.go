@@ -0,0 +1,219 @@
+package asm
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Disassemble returns the assembly source for mod. Assembling it yields mod
+// again. Each line is annotated with the position of its operation, and
+// source positions from the module's debug info are written as pos
+// directives wherever they change.
+func Disassemble(mod *pb.Module) string {
+	var b bytes.Buffer
+	// section separates the sections of the file with blank lines.
+	section := func() {
+		if b.Len() != 0 {
+			b.WriteString("\n")
+		}
+	}
+	if mod.Package != "" {
+		fmt.Fprintf(&b, "package %s\n", quote(mod.Package))
+	}
+	if len(mod.Symbols) != 0 {
+		section()
+	}
+	for _, sym := range mod.Symbols {
+		fmt.Fprintf(&b, "symbol %s\n", quote(sym))
+	}
+	info := mod.GetDebugInfo()
+	if len(info.GetFiles()) != 0 {
+		section()
+	}
+	for _, file := range info.GetFiles() {
+		fmt.Fprintf(&b, "file %s\n", quote(file))
+	}
+	if len(mod.Code) != 0 {
+		section()
+	}
+	labels := Labels(mod.Code)
+	last := "-"
+	for pc, op := range mod.Code {
+		if info != nil {
+			if src := formatPosition(info, pc); src != last {
+				fmt.Fprintf(&b, "pos %s\n", src)
+				last = src
+			}
+		}
+		text := Format(mod.Symbols, labels, op)
+		if op.GetLabel() == nil {
+			text = "\t" + text
+		}
+		fmt.Fprintf(&b, "%s%s# %d\n", text, padding(text), pc)
+	}
+	return b.String()
+}
+
+// formatPosition returns the argument of the pos directive for the
+// operation at pc.
+func formatPosition(info *pb.DebugInfo, pc int) string {
+	if len(info.Positions) <= pc {
+		return "-"
+	}
+	p := info.Positions[pc]
+	if p.Line == 0 || p.FileIdx < 0 || int(p.FileIdx) >= len(info.Files) {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d:%d", quote(info.Files[p.FileIdx]), p.Line, p.Column)
+}
+
+// padding returns the tabs which align a comment after text.
+func padding(text string) string {
+	width := len(text)
+	if strings.HasPrefix(text, "\t") {
+		width += 7
+	}
+	if 32 <= width {
+		return " "
+	}
+	return strings.Repeat("\t", 4-width/8)
+}
+
+// Labels maps the positions of the labels in code to their names, leaving
+// out labels whose names aren't unique or could be read as positions.
+func Labels(code []*pb.Operation) map[int32]string {
+	count := map[string]int{}
+	for _, op := range code {
+		if l := op.GetLabel(); l != nil {
+			count[l.Name]++
+		}
+	}
+	labels := map[int32]string{}
+	for pc, op := range code {
+		if l := op.GetLabel(); l != nil && count[l.Name] == 1 && !isNumber(l.Name) {
+			labels[int32(pc)] = l.Name
+		}
+	}
+	return labels
+}
+
+// Format returns the assembly for op, naming symbols from symbols and
+// targets from labels where possible.
+func Format(symbols []string, labels map[int32]string, o *pb.Operation) string {
+	target := func(t int32) string {
+		if name, ok := labels[t]; ok {
+			return quote(name)
+		}
+		return fmt.Sprint(t)
+	}
+	switch op := o.GetOp().(type) {
+	case *pb.Operation_Push:
+		if idx := int(op.Push.SymbolIdx); 0 <= idx && idx < len(symbols) && first(symbols, symbols[idx]) == idx {
+			return "push " + quote(symbols[idx])
+		}
+		return fmt.Sprintf("push %d", op.Push.SymbolIdx)
+	case *pb.Operation_Permute:
+		return formatPermute(op.Permute)
+	case *pb.Operation_Commit:
+		return "commit"
+	case *pb.Operation_Recall:
+		return fmt.Sprintf("recall %d", op.Recall.Index)
+	case *pb.Operation_Group:
+		return fmt.Sprintf("group %d", op.Group.Count)
+	case *pb.Operation_Ungroup:
+		return fmt.Sprintf("ungroup %d", op.Ungroup.Count)
+	case *pb.Operation_Label:
+		return quote(op.Label.Name) + ":"
+	case *pb.Operation_Jump:
+		return "jump " + target(op.Jump.Target)
+	case *pb.Operation_Branch:
+		return "branch " + target(op.Branch.Target)
+	case *pb.Operation_Call:
+		return "call " + target(op.Call.Target)
+	case *pb.Operation_Return:
+		return "return"
+	case *pb.Operation_Choice:
+		return "choice " + target(op.Choice.Target)
+	case *pb.Operation_Fail:
+		return "fail"
+	case *pb.Operation_Fresh:
+		return "fresh"
+	case *pb.Operation_Unify:
+		return "unify"
+	}
+	return fmt.Sprintf("unknown %v", o)
+}
+
+// formatPermute returns the assembly for p, using an alias if one applies.
+func formatPermute(p *pb.Permute) string {
+	switch {
+	case p.Pop == 1 && equal(p.Push, 0, 0):
+		return "dup"
+	case p.Pop == 2 && equal(p.Push, 0, 1):
+		return "swap"
+	case p.Pop == 1 && len(p.Push) == 0:
+		return "pop"
+	case len(p.Push) == 0:
+		return fmt.Sprintf("pop %d", p.Pop)
+	case 3 <= p.Pop && isRoll(p):
+		return fmt.Sprintf("roll %d", p.Pop)
+	}
+	s := []string{"permute", fmt.Sprint(p.Pop)}
+	for _, idx := range p.Push {
+		s = append(s, fmt.Sprint(idx))
+	}
+	return strings.Join(s, " ")
+}
+
+func isRoll(p *pb.Permute) bool {
+	if int(p.Pop) != len(p.Push) || p.Push[0] != 0 {
+		return false
+	}
+	for i, idx := range p.Push[1:] {
+		if idx != p.Pop-1-int32(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func equal(push []int32, want ...int32) bool {
+	if len(push) != len(want) {
+		return false
+	}
+	for i := range push {
+		if push[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// first returns the index of the first occurrence of name in symbols.
+func first(symbols []string, name string) int {
+	for idx, sym := range symbols {
+		if sym == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// quote returns name as written in assembly: quoted if it's empty, could be
+// read as a number, or holds characters such as spaces or '#' which can't be
+// written as is.
+func quote(name string) string {
+	if name == "" || isNumber(name) || strings.ContainsAny(name, " #") || strconv.Quote(name) != `"`+name+`"` {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+func isNumber(s string) bool {
+	_, err := number(s)
+	return err == nil
+}
@@ -0,0 +1,158 @@
+// Package ast declares the types used to represent the syntax tree of a
+// Stalog source file.
+package ast
+
+import (
+	"fmt"
+	"path"
+	"unicode"
+)
+
+// Pos is a position in a source file.
+type Pos struct {
+	Filename string
+	// Offset is the number of runes before the position.
+	Offset int
+	// Line and Column count from 1. Column counts runes.
+	Line, Column int
+}
+
+func (p Pos) String() string {
+	if p.Filename == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// Node is implemented by every node of the syntax tree.
+type Node interface {
+	Position() Pos
+}
+
+// Module is a parsed source file.
+type Module struct {
+	Pos        Pos
+	Package    string
+	PackagePos Pos
+	Imports    []*Import
+	Defs       []Def
+	// Comments lists every comment in the file, in order.
+	Comments []*Comment
+}
+
+// Comment is a # comment. Text includes the # but not the newline.
+type Comment struct {
+	Pos  Pos
+	Text string
+}
+
+// Import imports a package.
+//
+//	import "path/to/pkg"
+type Import struct {
+	Pos  Pos
+	Path string
+}
+
+// Name returns the name the imported package is referred to by, which is the
+// last element of its path.
+func (i *Import) Name() string {
+	return path.Base(i.Path)
+}
+
+// Def is a top-level definition: a *SymbolDef, *CodeDef or *Clause.
+type Def interface {
+	Node
+	isDef()
+}
+
+// SymbolDef declares a symbol.
+//
+//	symbol Name
+type SymbolDef struct {
+	Pos  Pos
+	Name string
+}
+
+// CodeDef defines a named block of code.
+//
+//	def name = Word word .
+type CodeDef struct {
+	Pos   Pos
+	Name  string
+	Words []*Word
+}
+
+// Word is a word in the body of a CodeDef. It pushes a symbol if its name is
+// a symbol name and calls a def otherwise.
+type Word struct {
+	Pos Pos
+	// Package is the name of the imported package declaring Name, or empty
+	// if Name is declared in this package.
+	Package string
+	Name    string
+}
+
+// IsSymbol reports whether w names a symbol rather than a def.
+func (w *Word) IsSymbol() bool {
+	return IsSymbolName(w.Name)
+}
+
+// Clause is a clause defining a relation. Facts have no Body.
+//
+//	head(Term, ...) :- goal(Term, ...), ... .
+type Clause struct {
+	Pos  Pos
+	Head *Goal
+	Body []*Goal
+}
+
+// Goal is the head of a clause or a goal in its body. Only goals in a body
+// may be qualified with a Package.
+type Goal struct {
+	Pos     Pos
+	Package string
+	Name    string
+	Args    []*Term
+}
+
+// Term is an argument of a goal: a symbol, possibly applied to arguments, or
+// a variable. Variables are never qualified with a Package.
+type Term struct {
+	Pos     Pos
+	Package string
+	Name    string
+	Args    []*Term
+}
+
+// QualifiedName returns name qualified with the package pkg, as written in
+// source: pkg:name, or just name if pkg is empty.
+func QualifiedName(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + ":" + name
+}
+
+// IsSymbolName reports whether name is a symbol name, which starts with an
+// uppercase letter, rather than a def name.
+func IsSymbolName(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func (m *Module) Position() Pos    { return m.Pos }
+func (c *Comment) Position() Pos   { return c.Pos }
+func (i *Import) Position() Pos    { return i.Pos }
+func (d *SymbolDef) Position() Pos { return d.Pos }
+func (d *CodeDef) Position() Pos   { return d.Pos }
+func (w *Word) Position() Pos      { return w.Pos }
+func (c *Clause) Position() Pos    { return c.Pos }
+func (g *Goal) Position() Pos      { return g.Pos }
+func (t *Term) Position() Pos      { return t.Pos }
+
+func (*SymbolDef) isDef() {}
+func (*CodeDef) isDef()   {}
+func (*Clause) isDef()    {}
@@ -0,0 +1,264 @@
+// Package check reports semantic errors in parsed Stalog modules: names
+// declared more than once, references to names which aren't declared, and
+// badly formed package names. It also warns about variables used only once,
+// which are often misspelled symbols.
+package check
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hjfreyer/stalog/ast"
+)
+
+// Error is a semantic error in a Stalog source file.
+type Error struct {
+	Pos ast.Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Pos, e.Msg)
+}
+
+// ErrorList is a list of semantic errors, in the order they appear in the
+// source.
+type ErrorList []*Error
+
+// Error returns the messages of the errors, one per line.
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Decls lists the names declared by a package.
+type Decls struct {
+	// Symbols maps the names of symbols to their declarations.
+	Symbols map[string]*ast.SymbolDef
+	// Defs maps the names of defs and relations to their first definition.
+	Defs map[string]ast.Def
+}
+
+// Module checks m, which must not import anything. If it finds any problems,
+// the error is an ErrorList.
+func Module(m *ast.Module) error {
+	_, err := Package([]*ast.Module{m}, nil)
+	return err
+}
+
+// Package checks the files making up a package together. imports maps the
+// names of the packages the files import to their declarations. It returns
+// the package's declarations, even if it finds problems, in which case the
+// error is an ErrorList.
+func Package(files []*ast.Module, imports map[string]*Decls) (*Decls, error) {
+	c := checker{
+		decls: &Decls{
+			Symbols: map[string]*ast.SymbolDef{},
+			Defs:    map[string]ast.Def{},
+		},
+		imports: imports,
+	}
+	c.pkg(files)
+	if len(c.errs) == 0 {
+		return c.decls, nil
+	}
+	sort.SliceStable(c.errs, func(i, j int) bool {
+		a, b := c.errs[i].Pos, c.errs[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return c.decls, c.errs
+}
+
+type checker struct {
+	decls   *Decls
+	imports map[string]*Decls
+	// file maps the names of the packages imported by the file being
+	// checked to their declarations, or to nil if they weren't loaded.
+	file map[string]*Decls
+	errs ErrorList
+}
+
+func (c *checker) errorf(pos ast.Pos, format string, args ...interface{}) {
+	c.errs = append(c.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) pkg(files []*ast.Module) {
+	for _, m := range files {
+		if ast.IsSymbolName(m.Package) {
+			c.errorf(m.PackagePos, "package name %s must start with a lowercase letter", m.Package)
+		}
+		if m.Package != files[0].Package {
+			c.errorf(m.PackagePos, "package %s; expected package %s, as in %s", m.Package, files[0].Package, files[0].Pos.Filename)
+		}
+	}
+	// Declare every name before checking references, so definitions can
+	// refer to ones later in the package.
+	for _, m := range files {
+		for _, def := range m.Defs {
+			c.declare(def)
+		}
+	}
+	for _, m := range files {
+		c.module(m)
+	}
+}
+
+func (c *checker) module(m *ast.Module) {
+	c.file = map[string]*Decls{}
+	for _, imp := range m.Imports {
+		if _, ok := c.file[imp.Name()]; ok {
+			c.errorf(imp.Pos, "package %s imported more than once", imp.Name())
+			continue
+		}
+		decls, ok := c.imports[imp.Name()]
+		if !ok {
+			c.errorf(imp.Pos, "imported package %q not loaded", imp.Path)
+		}
+		c.file[imp.Name()] = decls
+	}
+	for _, def := range m.Defs {
+		switch d := def.(type) {
+		case *ast.CodeDef:
+			for _, w := range d.Words {
+				if w.IsSymbol() {
+					c.symbol(w.Pos, w.Package, w.Name)
+				} else {
+					c.def(w.Pos, w.Package, w.Name)
+				}
+			}
+		case *ast.Clause:
+			c.terms(d.Head.Args)
+			for _, g := range d.Body {
+				c.def(g.Pos, g.Package, g.Name)
+				c.terms(g.Args)
+			}
+		}
+	}
+}
+
+// declare records the name declared by def.
+func (c *checker) declare(def ast.Def) {
+	switch d := def.(type) {
+	case *ast.SymbolDef:
+		if prev, ok := c.decls.Symbols[d.Name]; ok {
+			c.errorf(d.Pos, "symbol %s declared more than once; previously declared at %v", d.Name, prev.Pos)
+			return
+		}
+		c.decls.Symbols[d.Name] = d
+	case *ast.CodeDef:
+		if prev, ok := c.decls.Defs[d.Name]; ok {
+			c.errorf(d.Pos, "def %s defined more than once; previously defined at %v", d.Name, prev.Position())
+			return
+		}
+		c.decls.Defs[d.Name] = d
+	case *ast.Clause:
+		name := d.Head.Name
+		prev, ok := c.decls.Defs[name]
+		if !ok {
+			c.decls.Defs[name] = d
+		} else if _, ok := prev.(*ast.Clause); !ok {
+			c.errorf(d.Pos, "def %s defined more than once; previously defined at %v", name, prev.Position())
+		}
+	}
+}
+
+// scope returns the declarations of the package named pkg, which is this
+// package if pkg is empty, or nil if they aren't available.
+func (c *checker) scope(pos ast.Pos, pkg string) *Decls {
+	if pkg == "" {
+		return c.decls
+	}
+	decls, ok := c.file[pkg]
+	if !ok {
+		c.errorf(pos, "package %s not imported", pkg)
+	}
+	return decls
+}
+
+func (c *checker) symbol(pos ast.Pos, pkg, name string) {
+	decls := c.scope(pos, pkg)
+	if decls == nil {
+		return
+	}
+	if _, ok := decls.Symbols[name]; !ok {
+		c.errorf(pos, "undeclared symbol %s", ast.QualifiedName(pkg, name))
+	}
+}
+
+func (c *checker) def(pos ast.Pos, pkg, name string) {
+	decls := c.scope(pos, pkg)
+	if decls == nil {
+		return
+	}
+	if _, ok := decls.Defs[name]; !ok {
+		c.errorf(pos, "undefined def %s", ast.QualifiedName(pkg, name))
+	}
+}
+
+// terms checks the terms of a clause. A bare name which isn't a declared
+// symbol is a variable, but a name applied to arguments or qualified with a
+// package must be a symbol.
+func (c *checker) terms(terms []*ast.Term) {
+	for _, t := range terms {
+		if len(t.Args) != 0 || t.Package != "" {
+			c.symbol(t.Pos, t.Package, t.Name)
+			c.terms(t.Args)
+		}
+	}
+}
+
+// Singletons warns about the variables in the clauses of files, the files
+// of a package with declarations decls, which appear only once in their
+// clause. As any bare name which isn't a declared symbol is a variable, a
+// misspelled symbol becomes a variable matching anything, and usually
+// appears only once.
+func Singletons(files []*ast.Module, decls *Decls) ErrorList {
+	var warnings ErrorList
+	for _, m := range files {
+		for _, def := range m.Defs {
+			clause, ok := def.(*ast.Clause)
+			if !ok {
+				continue
+			}
+			// names lists the variables in order of first appearance, and
+			// first maps them to that appearance.
+			var names []string
+			first := map[string]ast.Pos{}
+			count := map[string]int{}
+			var vars func(terms []*ast.Term)
+			vars = func(terms []*ast.Term) {
+				for _, t := range terms {
+					if len(t.Args) != 0 || t.Package != "" {
+						vars(t.Args)
+						continue
+					}
+					if _, ok := decls.Symbols[t.Name]; ok {
+						continue
+					}
+					if count[t.Name] == 0 {
+						names = append(names, t.Name)
+						first[t.Name] = t.Pos
+					}
+					count[t.Name]++
+				}
+			}
+			vars(clause.Head.Args)
+			for _, g := range clause.Body {
+				vars(g.Args)
+			}
+			for _, name := range names {
+				if count[name] == 1 {
+					warnings = append(warnings, &Error{Pos: first[name], Msg: fmt.Sprintf("variable %s appears only once in its clause", name)})
+				}
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,192 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hjfreyer/stalog/ast"
+	"github.com/hjfreyer/stalog/parser"
+)
+
+func TestModule(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		// want lists the expected errors without their file names.
+		want []string
+	}{
+		{"ok", `package foo
+symbol Z
+symbol S
+def main = two .
+def two = S S Z .
+nat(Z).
+nat(S(X)) :- nat(X).
+`, nil},
+		{"uppercase package", "package Foo", []string{
+			"1:9: package name Foo must start with a lowercase letter",
+		}},
+		{"duplicate symbols", "package foo symbol Z symbol S\nsymbol Z symbol Z", []string{
+			"2:1: symbol Z declared more than once; previously declared at 1:13",
+			"2:10: symbol Z declared more than once; previously declared at 1:13",
+		}},
+		{"duplicate defs", "package foo def main = . def main = .\nmain.", []string{
+			"1:26: def main defined more than once; previously defined at 1:13",
+			"2:1: def main defined more than once; previously defined at 1:13",
+		}},
+		{"def after clauses", "package foo nat(X). nat(Y). def nat = .", []string{
+			"1:29: def nat defined more than once; previously defined at 1:13",
+		}},
+		{"undeclared in def", "package foo symbol Z def main = Z S nope Z .", []string{
+			"1:35: undeclared symbol S",
+			"1:37: undefined def nope",
+		}},
+		{"undeclared in clause", "package foo symbol Z nat(S(X)) :- nat(X), int(Z).", []string{
+			"1:26: undeclared symbol S",
+			"1:43: undefined def int",
+		}},
+		{"nested application", "package foo symbol S add(S(P(X))).", []string{
+			"1:28: undeclared symbol P",
+		}},
+	}
+
+	for _, tc := range tcs {
+		m, err := parser.ParseFile("", tc.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var got []string
+		if err := Module(m); err != nil {
+			for _, e := range err.(ErrorList) {
+				got = append(got, e.Error())
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: wrong errors. Got:\n%q\nwanted:\n%q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPackage(t *testing.T) {
+	nat, err := parser.ParseFile("nat.slm", "package nat symbol Z symbol S nat(Z). def one = S Z .")
+	if err != nil {
+		t.Fatal(err)
+	}
+	natDecls, err := Package([]*ast.Module{nat}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tcs = []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"ok", []string{
+			`package foo import "lib/nat" def main = nat:Z nat:one two .`,
+			`package foo import "nat" two :- nat:nat(nat:S(X)), three(X). three(X).`,
+		}, nil},
+		{"names shared between files", []string{
+			"package foo symbol Z def main = two .",
+			"package foo symbol Z def two = Z .",
+		}, []string{
+			"b.slm:1:13: symbol Z declared more than once; previously declared at a.slm:1:13",
+		}},
+		{"different packages", []string{
+			"package foo",
+			"package bar",
+		}, []string{
+			"b.slm:1:9: package bar; expected package foo, as in a.slm",
+		}},
+		{"undeclared qualified names", []string{
+			`package foo import "lib/nat" def main = nat:Y nat:two . two :- nat:add(nat:Z, X).`,
+		}, []string{
+			"a.slm:1:41: undeclared symbol nat:Y",
+			"a.slm:1:47: undefined def nat:two",
+			"a.slm:1:64: undefined def nat:add",
+		}},
+		{"imports are per file", []string{
+			`package foo import "lib/nat"`,
+			`package foo def main = nat:Z .`,
+		}, []string{
+			"b.slm:1:24: package nat not imported",
+		}},
+		{"qualified variable", []string{
+			`package foo import "nat" two :- nat:nat(nat:X).`,
+		}, []string{
+			"a.slm:1:41: undeclared symbol nat:X",
+		}},
+		{"duplicate import", []string{
+			`package foo import "nat" import "lib/nat"`,
+		}, []string{
+			"a.slm:1:26: package nat imported more than once",
+		}},
+		{"import not loaded", []string{
+			`package foo import "int"`,
+		}, []string{
+			`a.slm:1:13: imported package "int" not loaded`,
+		}},
+	}
+
+	for _, tc := range tcs {
+		var files []*ast.Module
+		for i, src := range tc.files {
+			m, err := parser.ParseFile(string(rune('a'+i))+".slm", src)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			files = append(files, m)
+		}
+		var got []string
+		if _, err := Package(files, map[string]*Decls{"nat": natDecls}); err != nil {
+			for _, e := range err.(ErrorList) {
+				got = append(got, e.Error())
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: wrong errors. Got:\n%q\nwanted:\n%q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSingletons(t *testing.T) {
+	files := []string{
+		`package nat
+symbol Z
+symbol S
+nat(Zz).
+nat(S(X)) :- nat(X).
+add(Z, Y, Y).
+add(S(X), Y, S(R)) :- add(X, Yy, R).
+first(X, Y, X) :- nat(foo:Q).
+`,
+		"package nat def main = X Y .",
+	}
+	var ms []*ast.Module
+	for i, src := range files {
+		m, err := parser.ParseFile(string(rune('a'+i))+".slm", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms = append(ms, m)
+	}
+	decls := &Decls{Symbols: map[string]*ast.SymbolDef{}}
+	for _, def := range ms[0].Defs {
+		if d, ok := def.(*ast.SymbolDef); ok {
+			decls.Symbols[d.Name] = d
+		}
+	}
+	want := []string{
+		"a.slm:4:5: variable Zz appears only once in its clause",
+		"a.slm:7:11: variable Y appears only once in its clause",
+		"a.slm:7:30: variable Yy appears only once in its clause",
+		"a.slm:8:10: variable Y appears only once in its clause",
+	}
+	var got []string
+	for _, w := range Singletons(ms, decls) {
+		got = append(got, w.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong warnings. Got:\n%q\nwanted:\n%q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hjfreyer/stalog/asm"
+)
+
+func asmCmd(args []string) error {
+	fs := flag.NewFlagSet("asm", flag.ContinueOnError)
+	out := fs.String("o", "", "output file (default: input with .slb extension)")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("expected exactly one assembly file")
+	}
+	src, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+	mod, err := asm.Assemble(files[0], string(src))
+	if err != nil {
+		return err
+	}
+	b, err := proto.Marshal(mod)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(files[0], filepath.Ext(files[0])) + ".slb"
+	}
+	return ioutil.WriteFile(*out, b, 0644)
+}
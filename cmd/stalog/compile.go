@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hjfreyer/stalog/compiler"
+	"github.com/hjfreyer/stalog/loader"
+	opt "github.com/hjfreyer/stalog/optimize"
+)
+
+func compileCmd(args []string) error {
+	fs := flag.NewFlagSet("compile", flag.ContinueOnError)
+	out := fs.String("o", "", "output file (default: first input with .slb extension)")
+	optimize := fs.Bool("O", false, "optimize the compiled code")
+	path := fs.String("path", os.Getenv("STALOGPATH"), "list of directories to search for imported packages (default: $STALOGPATH, or the directory of the first input)")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("expected source files")
+	}
+	config := loader.Config{Path: filepath.SplitList(*path)}
+	if len(config.Path) == 0 {
+		config.Path = []string{filepath.Dir(files[0])}
+	}
+	prog, err := config.LoadFiles(files...)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range prog.Packages {
+		for _, w := range pkg.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+		}
+	}
+	mod, err := compiler.CompileProgram(prog)
+	if err != nil {
+		return err
+	}
+	if *optimize {
+		mod = opt.Module(mod)
+	}
+	b, err := proto.Marshal(mod)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(files[0], filepath.Ext(files[0])) + ".slb"
+	}
+	return ioutil.WriteFile(*out, b, 0644)
+}
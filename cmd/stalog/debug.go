@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/hjfreyer/stalog/debug"
+)
+
+func debugCmd(args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ContinueOnError)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("expected exactly one module file")
+	}
+	mod, err := readModule(files[0])
+	if err != nil {
+		return err
+	}
+	d := debug.New(mod)
+
+	// Interrupts stop the running command rather than the debugger.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("(debug) ")
+		if !in.Scan() {
+			fmt.Println()
+			return in.Err()
+		}
+		line := in.Text()
+		if line == "quit" || line == "q" {
+			return nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-interrupts:
+				cancel()
+			case <-done:
+			}
+		}()
+		err := d.Command(ctx, os.Stdout, line)
+		close(done)
+		cancel()
+		if err != nil {
+			fmt.Println("error:", err)
+		}
+	}
+}
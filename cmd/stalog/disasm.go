@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hjfreyer/stalog/asm"
+)
+
+func disasmCmd(args []string) error {
+	fs := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("expected exactly one module file")
+	}
+	mod, err := readModule(files[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(asm.Disassemble(mod))
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hjfreyer/stalog/diff"
+	"github.com/hjfreyer/stalog/format"
+)
+
+func fmtCmd(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fs.Bool("w", false, "write the result back to the source files rather than to stdout")
+	showDiff := fs.Bool("d", false, "print diffs rather than the formatted source")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("expected source files")
+	}
+	for _, filename := range files {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		out, err := format.Source(filename, src)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(src, out) && (*write || *showDiff) {
+			continue
+		}
+		if *write {
+			if err := ioutil.WriteFile(filename, out, 0644); err != nil {
+				return err
+			}
+		}
+		if *showDiff {
+			os.Stdout.Write(diff.Unified(filename+".orig", filename, src, out))
+		}
+		if !*write && !*showDiff {
+			os.Stdout.Write(out)
+		}
+	}
+	return nil
+}
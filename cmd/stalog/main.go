@@ -0,0 +1,96 @@
+// Command stalog compiles, assembles, runs, inspects and formats Stalog
+// programs, evaluates operations interactively and debugs programs.
+//
+// Usage:
+//
+//	stalog compile foo.slm [bar.slm ...] [-o foo.slb] [-path dirs] [-O]
+//	stalog run foo.slb
+//	stalog asm foo.sla [-o foo.slb]
+//	stalog disasm foo.slb
+//	stalog fmt [-w] [-d] foo.slm ...
+//	stalog repl [foo.slb]
+//	stalog debug foo.slb
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"compile", "compile foo.slm [bar.slm ...] [-o foo.slb] [-path dirs] [-O]", compileCmd},
+		{"run", "run foo.slb", runCmd},
+		{"asm", "asm foo.sla [-o foo.slb]", asmCmd},
+		{"disasm", "disasm foo.slb", disasmCmd},
+		{"fmt", "fmt [-w] [-d] foo.slm ...", fmtCmd},
+		{"repl", "repl [foo.slb]", replCmd},
+		{"debug", "debug foo.slb", debugCmd},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: stalog <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\tstalog %s\n", c.usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "stalog %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+}
+
+// parseArgs parses flags in fs, allowing them to be interleaved with
+// positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func readModule(path string) (*pb.Module, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mod pb.Module
+	if err := proto.Unmarshal(b, &mod); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &mod, nil
+}
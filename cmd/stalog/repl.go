@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hjfreyer/stalog/repl"
+)
+
+func replCmd(args []string) error {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if 1 < len(files) {
+		return fmt.Errorf("expected at most one module file")
+	}
+	s := repl.New()
+	if len(files) == 1 {
+		mod, err := readModule(files[0])
+		if err != nil {
+			return err
+		}
+		s.Load(mod)
+	}
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !in.Scan() {
+			fmt.Println()
+			return in.Err()
+		}
+		if err := s.Eval(context.Background(), in.Text()); err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		printState(s.Runtime())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hjfreyer/stalog/asm"
+	"github.com/hjfreyer/stalog/runtime"
+	"github.com/hjfreyer/stalog/srcmap"
+	"github.com/hjfreyer/stalog/trace"
+	"github.com/hjfreyer/stalog/verify"
+)
+
+func runCmd(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	maxSteps := fs.Int("max-steps", 0, "maximum number of operations to evaluate per solution (0 for no limit)")
+	all := fs.Bool("all", false, "print every solution rather than just the first")
+	jsonOut := fs.Bool("json", false, "print each solution as a line of JSON holding its stack and log")
+	traceFmt := fs.String("trace", "", "trace each operation to stderr, as text or json")
+	check := fs.Bool("verify", true, "verify the module before running it, and skip the runtime checks verification makes unneeded")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("expected exactly one module file")
+	}
+	mod, err := readModule(files[0])
+	if err != nil {
+		return err
+	}
+	if *check {
+		if err := verify.Module(mod); err != nil {
+			return fmt.Errorf("%s: verification failed:\n%v", files[0], err)
+		}
+	}
+	src := srcmap.New(mod)
+	rt := runtime.Runtime{
+		Symbols:  mod.Symbols,
+		MaxSteps: *maxSteps,
+		Verified: *check,
+	}
+	switch *traceFmt {
+	case "":
+	case "text":
+		rt.Tracer = &trace.Text{W: os.Stderr, Labels: asm.Labels(mod.Code), Source: src}
+	case "json":
+		rt.Tracer = &trace.JSON{W: os.Stderr, Labels: asm.Labels(mod.Code), Source: src}
+	default:
+		return fmt.Errorf("unknown trace format %q", *traceFmt)
+	}
+	sols := rt.Solutions(mod.Code)
+	n := 0
+	for sols.Next(context.Background()) {
+		if *jsonOut {
+			if err := printJSON(&rt); err != nil {
+				return err
+			}
+		} else {
+			if *all {
+				fmt.Printf("Solution %d:\n", n)
+			}
+			printState(&rt)
+		}
+		n++
+		if !*all {
+			break
+		}
+	}
+	if err := sols.Err(); err != nil {
+		var e *runtime.EvalError
+		if errors.As(err, &e) {
+			if pos, ok := src.Pos(e.PC); ok {
+				return fmt.Errorf("%v: %v", pos, err)
+			}
+		}
+		return err
+	}
+	if n == 0 && !*jsonOut {
+		fmt.Println("No solutions.")
+	}
+	return nil
+}
+
+func printState(rt *runtime.Runtime) {
+	fmt.Println("Stack:")
+	for _, v := range rt.Stack {
+		fmt.Printf("\t%s\n", rt.Format(v))
+	}
+	fmt.Println("Log:")
+	for _, v := range rt.Log {
+		fmt.Printf("\t%s\n", rt.Format(v))
+	}
+}
+
+// printJSON prints the runtime's stack and log as a JSON object, with values
+// encoded by Runtime.EncodeJSON.
+func printJSON(rt *runtime.Runtime) error {
+	encode := func(values []runtime.Value) ([]json.RawMessage, error) {
+		out := []json.RawMessage{}
+		for _, v := range values {
+			b, err := rt.EncodeJSON(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b)
+		}
+		return out, nil
+	}
+	stack, err := encode(rt.Stack)
+	if err != nil {
+		return err
+	}
+	log, err := encode(rt.Log)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(struct {
+		Stack []json.RawMessage `json:"stack"`
+		Log   []json.RawMessage `json:"log"`
+	}{stack, log})
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -0,0 +1,157 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/hjfreyer/stalog/ast"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// relation compiles the clauses defining a relation. A relation is called
+// with its arguments on the stack, first argument deepest, and consumes them.
+// Each clause but the last is preceded by a Choice of the next, so failing to
+// unify a clause's head or prove its body moves on to the next clause.
+func (c *compiler) relation(name string, clauses []ast.Def) error {
+	c.labels[name] = int32(len(c.mod.Code))
+	c.pos = clauses[0].Position()
+	c.emit(&pb.Operation{Op: &pb.Operation_Label{Label: &pb.Label{Name: name}}})
+	for i, clause := range clauses {
+		c.pos = clause.Position()
+		var next *pb.Choice
+		if i < len(clauses)-1 {
+			next = &pb.Choice{}
+			c.emit(&pb.Operation{Op: &pb.Operation_Choice{Choice: next}})
+		}
+		if err := c.clause(clause.(*ast.Clause)); err != nil {
+			return err
+		}
+		if next != nil {
+			next.Target = int32(len(c.mod.Code))
+		}
+	}
+	return nil
+}
+
+// frame tracks the stack while compiling a clause. The bottom of the frame
+// holds the clause's arguments followed by its variables.
+type frame struct {
+	// vars maps the names of variables to their positions in the frame.
+	vars map[string]int
+	// height is the number of values in the frame.
+	height int
+}
+
+// clause compiles a single clause. It creates a fresh variable for each
+// variable in the clause, unifies each argument with the corresponding
+// term in the head, calls each goal in the body, and finally pops its
+// arguments and variables.
+func (c *compiler) clause(cl *ast.Clause) error {
+	f := &frame{
+		vars:   map[string]int{},
+		height: len(cl.Head.Args),
+	}
+	c.collectVars(f, cl.Head.Args)
+	for _, g := range cl.Body {
+		c.collectVars(f, g.Args)
+	}
+	c.pos = cl.Pos
+	for range f.vars {
+		c.emit(&pb.Operation{Op: &pb.Operation_Fresh{Fresh: &pb.Fresh{}}})
+	}
+	f.height += len(f.vars)
+
+	for i, arg := range cl.Head.Args {
+		if err := c.term(f, arg); err != nil {
+			return err
+		}
+		c.pos = arg.Pos
+		c.pick(f, i)
+		c.emit(&pb.Operation{Op: &pb.Operation_Unify{Unify: &pb.Unify{}}})
+		f.height -= 2
+	}
+
+	for _, g := range cl.Body {
+		for _, arg := range g.Args {
+			if err := c.term(f, arg); err != nil {
+				return err
+			}
+		}
+		c.pos = g.Pos
+		c.emitCall(g.Pos, c.qualify(g.Package, g.Name), len(g.Args))
+		f.height -= len(g.Args)
+	}
+
+	c.pos = cl.Pos
+	if 0 < f.height {
+		c.emit(&pb.Operation{Op: &pb.Operation_Permute{Permute: &pb.Permute{Pop: int32(f.height)}}})
+	}
+	c.emit(&pb.Operation{Op: &pb.Operation_Return{Return: &pb.Return{}}})
+	return nil
+}
+
+// isVar reports whether t is a variable: a bare, unqualified name that isn't
+// a declared symbol.
+func (c *compiler) isVar(t *ast.Term) bool {
+	if len(t.Args) != 0 || t.Package != "" {
+		return false
+	}
+	_, ok := c.symbolIdx[c.qualify("", t.Name)]
+	return !ok
+}
+
+// collectVars assigns frame positions to the variables in terms, in order of
+// first appearance.
+func (c *compiler) collectVars(f *frame, terms []*ast.Term) {
+	for _, t := range terms {
+		if !c.isVar(t) {
+			c.collectVars(f, t.Args)
+			continue
+		}
+		if _, ok := f.vars[t.Name]; !ok {
+			f.vars[t.Name] = f.height + len(f.vars)
+		}
+	}
+}
+
+// term pushes the value of a term. Symbols are pushed, variables copied from
+// the frame, and applications of a symbol to arguments grouped into a Tree
+// whose first child is the symbol.
+func (c *compiler) term(f *frame, t *ast.Term) error {
+	c.pos = t.Pos
+	if c.isVar(t) {
+		c.pick(f, f.vars[t.Name])
+		return nil
+	}
+	name := c.qualify(t.Package, t.Name)
+	idx, ok := c.symbolIdx[name]
+	if !ok {
+		return fmt.Errorf("%v: undeclared symbol %s", t.Pos, name)
+	}
+	c.emit(&pb.Operation{Op: &pb.Operation_Push{Push: &pb.Push{SymbolIdx: idx}}})
+	f.height++
+
+	if len(t.Args) == 0 {
+		return nil
+	}
+	for _, arg := range t.Args {
+		if err := c.term(f, arg); err != nil {
+			return err
+		}
+	}
+	c.pos = t.Pos
+	c.emit(&pb.Operation{Op: &pb.Operation_Group{Group: &pb.Group{Count: int32(len(t.Args) + 1)}}})
+	f.height -= len(t.Args)
+	return nil
+}
+
+// pick pushes a copy of the value at position pos in the frame.
+func (c *compiler) pick(f *frame, pos int) {
+	depth := int32(f.height - 1 - pos)
+	p := &pb.Permute{Pop: depth + 1}
+	for i := depth; 0 <= i; i-- {
+		p.Push = append(p.Push, i)
+	}
+	p.Push = append(p.Push, depth)
+	c.emit(&pb.Operation{Op: &pb.Operation_Permute{Permute: p}})
+	f.height++
+}
@@ -0,0 +1,258 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/hjfreyer/stalog/ast"
+	"github.com/hjfreyer/stalog/check"
+	"github.com/hjfreyer/stalog/loader"
+	"github.com/hjfreyer/stalog/parser"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// entryPoint is the name of the definition called when a module is run.
+const entryPoint = "main"
+
+// Compile parses src as the Stalog source file named filename and compiles
+// it to bytecode.
+//
+// Each def, and each relation defined by clauses, is compiled to a block of
+// code starting with a Label and ending in a Return. If the module has any,
+// the code begins with a preamble that calls main, if defined, and then
+// jumps past the blocks.
+//
+// The module's debug info holds the position in the source of each
+// operation's def, word, clause, term or goal.
+func Compile(filename, src string) (*pb.Module, error) {
+	m, err := parser.ParseFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return CompileModule(m)
+}
+
+// CompileModule compiles a parsed module, which must not import anything, to
+// bytecode. The module is checked first, and if that finds problems the error
+// is a check.ErrorList.
+func CompileModule(m *ast.Module) (*pb.Module, error) {
+	if err := check.Module(m); err != nil {
+		return nil, err
+	}
+	pkg := &loader.Package{
+		Name:  m.Package,
+		Files: []*ast.Module{m},
+	}
+	return CompileProgram(&loader.Program{
+		Main:     pkg,
+		Packages: []*loader.Package{pkg},
+	})
+}
+
+// CompileProgram compiles a loaded program to a single bytecode module. The
+// names of symbols and defs from packages other than the main package are
+// qualified with their package's name, as in nat:Z, in the module's symbol
+// table and labels.
+func CompileProgram(prog *loader.Program) (*pb.Module, error) {
+	c := compiler{
+		symbolIdx: map[string]int32{},
+		defs:      map[string][]ast.Def{},
+		defPkgs:   map[string]string{},
+		arities:   map[string]int{},
+		labels:    map[string]int32{},
+	}
+	if err := c.program(prog); err != nil {
+		return nil, err
+	}
+	return c.mod, nil
+}
+
+type compiler struct {
+	mod *pb.Module
+	// pkg is the name qualifying the names declared by the package being
+	// compiled, which is empty for the main package.
+	pkg string
+	// symbolIdx maps qualified names of symbols to their indices.
+	symbolIdx map[string]int32
+
+	// defs maps qualified names of defs to their CodeDef or, for relations,
+	// their Clauses. defOrder lists the names in source order, and defPkgs
+	// maps them to the pkg they were declared in.
+	defs     map[string][]ast.Def
+	defOrder []string
+	defPkgs  map[string]string
+	// arities maps names of relations to their number of arguments.
+	arities map[string]int
+
+	// labels maps names of defs to the position of their code.
+	labels map[string]int32
+	// calls lists the Call operations which need their targets set once
+	// all labels are known.
+	calls []pendingCall
+
+	// pos is the source position recorded for the operations emitted, and
+	// fileIdx maps filenames to their indices in the debug info.
+	pos     ast.Pos
+	fileIdx map[string]int32
+}
+
+type pendingCall struct {
+	op   *pb.Call
+	pos  ast.Pos
+	name string
+	// arity is the number of arguments passed to a relation, or -1 if the
+	// call isn't from a clause.
+	arity int
+}
+
+func (c *compiler) program(prog *loader.Program) error {
+	c.mod = &pb.Module{
+		Package:   prog.Main.Name,
+		DebugInfo: &pb.DebugInfo{},
+	}
+	c.fileIdx = map[string]int32{}
+	for _, pkg := range prog.Packages {
+		c.pkg = ""
+		if pkg != prog.Main {
+			c.pkg = pkg.Name
+		}
+		for _, m := range pkg.Files {
+			for _, def := range m.Defs {
+				if err := c.definition(def); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if len(c.defs) == 0 {
+		c.mod.DebugInfo = nil
+		return nil
+	}
+
+	if defs, ok := c.defs[entryPoint]; ok {
+		if 0 < c.arities[entryPoint] {
+			return fmt.Errorf("%v: %s must not take arguments", defs[0].Position(), entryPoint)
+		}
+		c.pos = prog.Main.Files[0].Pos
+		c.emitCall(c.pos, entryPoint, -1)
+	}
+	c.pos = prog.Main.Files[0].Pos
+	end := &pb.Jump{}
+	c.emit(&pb.Operation{Op: &pb.Operation_Jump{Jump: end}})
+	for _, name := range c.defOrder {
+		defs := c.defs[name]
+		c.pkg = c.defPkgs[name]
+		var err error
+		if def, ok := defs[0].(*ast.CodeDef); ok {
+			err = c.codeDef(name, def)
+		} else {
+			err = c.relation(name, defs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	end.Target = int32(len(c.mod.Code))
+
+	for _, call := range c.calls {
+		target, ok := c.labels[call.name]
+		if !ok {
+			return fmt.Errorf("%v: undefined def %s", call.pos, call.name)
+		}
+		if arity, ok := c.arities[call.name]; ok && 0 <= call.arity && call.arity != arity {
+			return fmt.Errorf("%v: %s called with %d arguments; it takes %d", call.pos, call.name, call.arity, arity)
+		}
+		call.op.Target = target
+	}
+	return nil
+}
+
+// qualify returns the qualified name of the name declared in the package
+// named pkg, or in the package being compiled if pkg is empty.
+func (c *compiler) qualify(pkg, name string) string {
+	if pkg == "" {
+		pkg = c.pkg
+	}
+	return ast.QualifiedName(pkg, name)
+}
+
+// definition records a definition. Symbols are added to the symbol table
+// immediately, while defs are compiled after all names are known.
+func (c *compiler) definition(def ast.Def) error {
+	switch d := def.(type) {
+	case *ast.SymbolDef:
+		name := c.qualify("", d.Name)
+		if _, ok := c.symbolIdx[name]; ok {
+			return fmt.Errorf("%v: symbol %s declared more than once", d.Pos, name)
+		}
+		c.symbolIdx[name] = int32(len(c.mod.Symbols))
+		c.mod.Symbols = append(c.mod.Symbols, name)
+	case *ast.CodeDef:
+		name := c.qualify("", d.Name)
+		if _, ok := c.defs[name]; ok {
+			return fmt.Errorf("%v: def %s defined more than once", d.Pos, name)
+		}
+		c.defs[name] = []ast.Def{d}
+		c.defOrder = append(c.defOrder, name)
+		c.defPkgs[name] = c.pkg
+	case *ast.Clause:
+		name, arity := c.qualify("", d.Head.Name), len(d.Head.Args)
+		clauses, ok := c.defs[name]
+		if !ok {
+			c.defOrder = append(c.defOrder, name)
+			c.defPkgs[name] = c.pkg
+			c.arities[name] = arity
+		} else if _, ok := clauses[0].(*ast.Clause); !ok {
+			return fmt.Errorf("%v: def %s defined more than once", d.Pos, name)
+		} else if c.arities[name] != arity {
+			return fmt.Errorf("%v: clauses for %s have different numbers of arguments", d.Pos, name)
+		}
+		c.defs[name] = append(clauses, d)
+	}
+	return nil
+}
+
+// codeDef compiles the body of the def with the given qualified name. Symbol
+// names push the symbol and def names call the def.
+func (c *compiler) codeDef(name string, d *ast.CodeDef) error {
+	c.labels[name] = int32(len(c.mod.Code))
+	c.pos = d.Pos
+	c.emit(&pb.Operation{Op: &pb.Operation_Label{Label: &pb.Label{Name: name}}})
+	for _, w := range d.Words {
+		c.pos = w.Pos
+		qualified := c.qualify(w.Package, w.Name)
+		if !w.IsSymbol() {
+			c.emitCall(w.Pos, qualified, -1)
+			continue
+		}
+		idx, ok := c.symbolIdx[qualified]
+		if !ok {
+			return fmt.Errorf("%v: undeclared symbol %s", w.Pos, qualified)
+		}
+		c.emit(&pb.Operation{Op: &pb.Operation_Push{Push: &pb.Push{SymbolIdx: idx}}})
+	}
+	c.pos = d.Pos
+	c.emit(&pb.Operation{Op: &pb.Operation_Return{Return: &pb.Return{}}})
+	return nil
+}
+
+// emit appends op to the code, recording c.pos as its position.
+func (c *compiler) emit(op *pb.Operation) {
+	c.mod.Code = append(c.mod.Code, op)
+	idx, ok := c.fileIdx[c.pos.Filename]
+	if !ok {
+		idx = int32(len(c.mod.DebugInfo.Files))
+		c.fileIdx[c.pos.Filename] = idx
+		c.mod.DebugInfo.Files = append(c.mod.DebugInfo.Files, c.pos.Filename)
+	}
+	c.mod.DebugInfo.Positions = append(c.mod.DebugInfo.Positions, &pb.Position{
+		FileIdx: idx,
+		Line:    int32(c.pos.Line),
+		Column:  int32(c.pos.Column),
+	})
+}
+
+func (c *compiler) emitCall(pos ast.Pos, def string, arity int) {
+	op := &pb.Call{}
+	c.calls = append(c.calls, pendingCall{op: op, pos: pos, name: def, arity: arity})
+	c.emit(&pb.Operation{Op: &pb.Operation_Call{Call: op}})
+}
@@ -0,0 +1,404 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hjfreyer/stalog/loader"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+)
+
+func push(idx int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Push{Push: &pb.Push{SymbolIdx: idx}}}
+}
+
+func label(name string) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Label{Label: &pb.Label{Name: name}}}
+}
+
+func jump(target int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Jump{Jump: &pb.Jump{Target: target}}}
+}
+
+func call(target int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Call{Call: &pb.Call{Target: target}}}
+}
+
+var ret = &pb.Operation{Op: &pb.Operation_Return{Return: &pb.Return{}}}
+
+func choice(target int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Choice{Choice: &pb.Choice{Target: target}}}
+}
+
+func group(count int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Group{Group: &pb.Group{Count: count}}}
+}
+
+func permute(pop int32, push ...int32) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Permute{Permute: &pb.Permute{Pop: pop, Push: push}}}
+}
+
+// pick copies the value depth below the top of the stack to the top.
+func pick(depth int32) *pb.Operation {
+	var push []int32
+	for i := depth; 0 <= i; i-- {
+		push = append(push, i)
+	}
+	return permute(depth+1, append(push, depth)...)
+}
+
+func pop(n int32) *pb.Operation {
+	return permute(n)
+}
+
+var fresh = &pb.Operation{Op: &pb.Operation_Fresh{Fresh: &pb.Fresh{}}}
+var unify = &pb.Operation{Op: &pb.Operation_Unify{Unify: &pb.Unify{}}}
+
+func TestCompile(t *testing.T) {
+	var tcs = []struct {
+		name    string
+		src     string
+		want    *pb.Module
+		wantErr bool
+	}{
+		{
+			name: "empty module",
+			src:  "package foo",
+			want: &pb.Module{Package: "foo"},
+		}, {
+			name: "symbols",
+			src: `
+# Check
+package   foo
+
+symbol Z
+symbol S
+`,
+			want: &pb.Module{
+				Package: "foo",
+				Symbols: []string{"Z", "S"},
+			},
+		}, {
+			name:    "duplicate symbol",
+			src:     "package foo symbol Z symbol Z",
+			wantErr: true,
+		}, {
+			name: "def",
+			src: `package foo
+symbol Z
+symbol S
+def two = S S Z .
+`,
+			want: &pb.Module{
+				Package: "foo",
+				Symbols: []string{"Z", "S"},
+				Code: []*pb.Operation{
+					jump(6),
+					label("two"), push(1), push(1), push(0), ret,
+				},
+			},
+		}, {
+			name: "main and forward reference",
+			src: `package foo
+symbol Z
+def main = one one .
+def one = Z .
+`,
+			want: &pb.Module{
+				Package: "foo",
+				Symbols: []string{"Z"},
+				Code: []*pb.Operation{
+					call(2), jump(9),
+					label("main"), call(6), call(6), ret,
+					label("one"), push(0), ret,
+				},
+			},
+		}, {
+			name:    "uppercase package",
+			src:     "package Foo",
+			wantErr: true,
+		}, {
+			name:    "undefined def",
+			src:     "package foo def main = nope .",
+			wantErr: true,
+		}, {
+			name:    "undeclared symbol",
+			src:     "package foo def main = Z .",
+			wantErr: true,
+		}, {
+			name:    "duplicate def",
+			src:     "package foo def main = . def main = .",
+			wantErr: true,
+		}, {
+			name: "clauses",
+			src: `package foo
+symbol Z
+symbol S
+nat(Z).
+nat(S(X)) :- nat(X).
+`,
+			want: &pb.Module{
+				Package: "foo",
+				Symbols: []string{"Z", "S"},
+				Code: []*pb.Operation{
+					jump(18),
+					label("nat"),
+					choice(8),
+					// nat(Z).
+					push(0), pick(1), unify, pop(1), ret,
+					// nat(S(X)) :- nat(X).
+					fresh,
+					push(1), pick(1), group(2), pick(2), unify,
+					pick(0), call(1),
+					pop(2), ret,
+				},
+			},
+		}, {
+			name:    "arity mismatch",
+			src:     "package foo symbol Z nat(Z). nat(Z, Z).",
+			wantErr: true,
+		}, {
+			name:    "call arity mismatch",
+			src:     "package foo symbol Z nat(Z). main :- nat(Z, Z).",
+			wantErr: true,
+		}, {
+			name:    "clause and def",
+			src:     "package foo symbol Z def nat = . nat(Z).",
+			wantErr: true,
+		}, {
+			name:    "undeclared symbol applied",
+			src:     "package foo nat(S(X)).",
+			wantErr: true,
+		}, {
+			name:    "main with arguments",
+			src:     "package foo symbol Z main(Z).",
+			wantErr: true,
+		}, {
+			name:    "no package",
+			src:     "symbol Z",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		got, err := Compile("", tc.src)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %v", tc.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		// Debug info is tested by TestDebugInfo.
+		got.DebugInfo = nil
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%s: wrong module. Got:\n%v; wanted:\n%v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDebugInfo(t *testing.T) {
+	mod, err := Compile("foo.slm", `package foo
+symbol Z
+symbol S
+def two = S
+  S Z .
+nat(Z).
+nat(S(X)) :-
+  nat(X).
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"foo.slm:1:1", // jump
+		"foo.slm:4:1", // two:
+		"foo.slm:4:11",
+		"foo.slm:5:3",
+		"foo.slm:5:5",
+		"foo.slm:4:1", // return
+		"foo.slm:6:1", // nat:
+		"foo.slm:6:1", // choice
+		"foo.slm:6:5", // push Z
+		"foo.slm:6:5",
+		"foo.slm:6:5",
+		"foo.slm:6:1", // pop
+		"foo.slm:6:1",
+		"foo.slm:7:1", // fresh
+		"foo.slm:7:5", // push S
+		"foo.slm:7:7", // pick X
+		"foo.slm:7:5", // group
+		"foo.slm:7:5",
+		"foo.slm:7:5", // unify
+		"foo.slm:8:7", // pick X
+		"foo.slm:8:3", // call nat
+		"foo.slm:7:1", // pop
+		"foo.slm:7:1",
+	}
+	info := mod.DebugInfo
+	if len(info.Positions) != len(mod.Code) {
+		t.Fatalf("got %d positions for %d operations", len(info.Positions), len(mod.Code))
+	}
+	var got []string
+	for _, p := range info.Positions {
+		got = append(got, fmt.Sprintf("%s:%d:%d", info.Files[p.FileIdx], p.Line, p.Column))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong positions. Got:\n%v; wanted:\n%v", got, want)
+	}
+}
+
+func TestCompileExample(t *testing.T) {
+	src, err := ioutil.ReadFile("../examples/nat.slm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := Compile("nat.slm", string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded pb.Module
+	if err := proto.Unmarshal(b, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Code) == 0 {
+		t.Error("no code compiled")
+	}
+	if loaded.Package != "nat" {
+		t.Errorf("wrong package: %q", loaded.Package)
+	}
+	if want := []string{"Z", "S"}; !reflect.DeepEqual(loaded.Symbols, want) {
+		t.Errorf("wrong symbols. Got %v; wanted %v", loaded.Symbols, want)
+	}
+}
+
+func TestCompileAndRun(t *testing.T) {
+	mod, err := Compile("", `package foo
+symbol Z
+symbol S
+def main = Z succ succ .
+def succ = S .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := runtime.Runtime{Symbols: mod.Symbols}
+	if err := rt.Run(context.Background(), mod.Code); err != nil {
+		t.Fatal(err)
+	}
+	want := []runtime.Value{runtime.Symbol(0), runtime.Symbol(1), runtime.Symbol(1)}
+	if !reflect.DeepEqual(rt.Stack, want) {
+		t.Errorf("wrong stack. Got:\n%v; wanted:\n%v", rt.Stack, want)
+	}
+}
+
+func TestCompileAndSolve(t *testing.T) {
+	src, err := ioutil.ReadFile("../examples/nat.slm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tcs = []struct {
+		main      string
+		solutions int
+	}{
+		{"main :- nat(Z).", 1},
+		{"main :- nat(S(S(Z))).", 1},
+		{"main :- add(S(Z), S(Z), S(S(Z))).", 1},
+		{"main :- add(S(Z), S(Z), S(Z)).", 0},
+		{"main :- add(X, Y, S(S(Z))).", 3},
+		{"main :- add(X, S(Z), S(S(Z))), nat(X), add(X, X, S(S(Z))).", 1},
+		{"main :- add(X, X, S(S(S(Z)))).", 0},
+	}
+	for _, tc := range tcs {
+		mod, err := Compile("nat.slm", string(src)+tc.main)
+		if err != nil {
+			t.Errorf("%s: %v", tc.main, err)
+			continue
+		}
+		rt := runtime.Runtime{
+			Symbols:  mod.Symbols,
+			MaxSteps: 10000,
+		}
+		sols := rt.Solutions(mod.Code)
+		n := 0
+		for n <= tc.solutions && sols.Next(context.Background()) {
+			n++
+		}
+		if err := sols.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.main, err)
+		}
+		if n != tc.solutions {
+			t.Errorf("%s: got %d solutions; wanted %d", tc.main, n, tc.solutions)
+		}
+	}
+}
+
+func TestCompileProgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compiler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "nat"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"main.slm":    `package foo import "nat" symbol Z def main = Z nat:one . nat(X) :- nat:nat(nat:S(X)).`,
+		"nat/nat.slm": "package nat symbol Z symbol S def one = S Z . nat(Z). nat(S(X)) :- nat(X).",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := loader.Config{Path: []string{dir}}
+	prog, err := config.LoadFiles(filepath.Join(dir, "main.slm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := CompileProgram(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod.Package != "foo" {
+		t.Errorf("wrong package: %q", mod.Package)
+	}
+	if want := []string{"nat:Z", "nat:S", "Z"}; !reflect.DeepEqual(mod.Symbols, want) {
+		t.Errorf("wrong symbols. Got %v; wanted %v", mod.Symbols, want)
+	}
+	var labels []string
+	for _, op := range mod.Code {
+		if l := op.GetLabel(); l != nil {
+			labels = append(labels, l.Name)
+		}
+	}
+	if want := []string{"nat:one", "nat:nat", "main", "nat"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("wrong labels. Got %v; wanted %v", labels, want)
+	}
+
+	rt := runtime.Runtime{Symbols: mod.Symbols}
+	if err := rt.Run(context.Background(), mod.Code); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range rt.Stack {
+		got = append(got, rt.Format(v))
+	}
+	if want := []string{"Z", "nat:S", "nat:Z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong stack. Got %v; wanted %v", got, want)
+	}
+}
@@ -0,0 +1,358 @@
+// Package debug runs a module under a runtime.Runtime one operation at a time,
+// pausing at breakpoints so that its state can be inspected.
+//
+// A Debugger can be driven through its methods, or by text commands:
+//
+//	break loc     (b)  set a breakpoint
+//	delete loc    (d)  delete a breakpoint
+//	breakpoints        list the breakpoints
+//	step [n]      (s)  evaluate n operations, default 1
+//	next          (n)  evaluate an operation, stepping over calls
+//	continue      (c)  run until a breakpoint, solution or error
+//	restart       (r)  start the program again
+//	where         (w)  show the current position and call stack
+//	list          (l)  show the code around the current position
+//	stack, log         show the stack or log
+//	help          (h)  show this help
+//
+// A location is a position in the code, like 12, the name of a label, like
+// nat:add, or, if the module has debug info, a line of source, like
+// nat.slm:8, which stands for the first operation compiled from it.
+// Breakpoints stop execution before the operation at their position is
+// evaluated. Positions are shown with their source positions where known.
+//
+// When the program runs to its end, the stack and log hold a solution.
+// Stepping or continuing from there backtracks to look for the next one.
+package debug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hjfreyer/stalog/asm"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+	"github.com/hjfreyer/stalog/srcmap"
+)
+
+// Stop is the reason execution stopped.
+type Stop int
+
+const (
+	// Paused means stepping finished.
+	Paused Stop = iota
+	// Breakpoint means execution reached a breakpoint.
+	Breakpoint
+	// Solution means the program ran to its end, so the runtime's stack
+	// and log hold a solution.
+	Solution
+	// Done means the program has no more solutions.
+	Done
+)
+
+// Debugger runs a module under a runtime.
+type Debugger struct {
+	mod *pb.Module
+	rt  runtime.Runtime
+	// labels maps the names of the module's labels to their positions, and
+	// targets the positions back to the names, as by asm.Labels.
+	labels  map[string]int
+	targets map[int32]string
+	src     *srcmap.Map
+	// breakpoints holds the positions of the breakpoints.
+	breakpoints map[int]bool
+}
+
+// New returns a debugger for mod, paused before its first operation.
+func New(mod *pb.Module) *Debugger {
+	d := &Debugger{
+		mod:         mod,
+		labels:      map[string]int{},
+		targets:     asm.Labels(mod.Code),
+		src:         srcmap.New(mod),
+		breakpoints: map[int]bool{},
+	}
+	for pc, name := range d.targets {
+		d.labels[name] = int(pc)
+	}
+	d.Restart()
+	return d
+}
+
+// Restart resets the runtime to run the program from the start. Breakpoints
+// are kept.
+func (d *Debugger) Restart() {
+	d.rt = runtime.Runtime{Symbols: d.mod.Symbols}
+}
+
+// Runtime returns the debugger's runtime.
+func (d *Debugger) Runtime() *runtime.Runtime {
+	return &d.rt
+}
+
+// Break sets a breakpoint at loc and returns its position.
+func (d *Debugger) Break(loc string) (int, error) {
+	pc, err := d.locate(loc)
+	if err != nil {
+		return 0, err
+	}
+	d.breakpoints[pc] = true
+	return pc, nil
+}
+
+// Delete deletes the breakpoint at loc.
+func (d *Debugger) Delete(loc string) error {
+	pc, err := d.locate(loc)
+	if err != nil {
+		return err
+	}
+	if !d.breakpoints[pc] {
+		return fmt.Errorf("no breakpoint at %s", loc)
+	}
+	delete(d.breakpoints, pc)
+	return nil
+}
+
+// Breakpoints returns the positions of the breakpoints, in order.
+func (d *Debugger) Breakpoints() []int {
+	var pcs []int
+	for pc := range d.breakpoints {
+		pcs = append(pcs, pc)
+	}
+	sort.Ints(pcs)
+	return pcs
+}
+
+// locate returns the position of loc.
+func (d *Debugger) locate(loc string) (int, error) {
+	if pc, err := strconv.Atoi(loc); err == nil {
+		if pc < 0 || len(d.mod.Code) <= pc {
+			return 0, fmt.Errorf("position %d outside the code", pc)
+		}
+		return pc, nil
+	}
+	if i := strings.LastIndex(loc, ":"); 0 <= i {
+		if line, err := strconv.Atoi(loc[i+1:]); err == nil {
+			if !d.src.Known() {
+				return 0, errors.New("module has no line information")
+			}
+			if pc, ok := d.src.Line(loc[:i], line); ok {
+				return pc, nil
+			}
+			return 0, fmt.Errorf("no code at %s", loc)
+		}
+	}
+	if pc, ok := d.labels[loc]; ok {
+		return pc, nil
+	}
+	return 0, fmt.Errorf("no label %s", loc)
+}
+
+// Step evaluates the next operation. At the end of the program, it
+// backtracks instead.
+func (d *Debugger) Step() (Stop, error) {
+	code := d.mod.Code
+	if d.rt.PC == len(code) {
+		if !d.rt.Backtrack() {
+			return Done, nil
+		}
+		return Paused, nil
+	}
+	err := d.rt.Step(code)
+	if errors.Is(err, runtime.Failed) {
+		return Done, nil
+	}
+	if err != nil {
+		return Paused, err
+	}
+	if d.rt.PC == len(code) {
+		return Solution, nil
+	}
+	return Paused, nil
+}
+
+// Next evaluates the next operation like Step, but if it's a call, runs
+// until the call returns.
+func (d *Debugger) Next(ctx context.Context) (Stop, error) {
+	depth := len(d.rt.CallStack)
+	return d.run(ctx, func() bool {
+		return len(d.rt.CallStack) <= depth
+	})
+}
+
+// Continue runs until a breakpoint is reached, the program ends or an error
+// occurs.
+func (d *Debugger) Continue(ctx context.Context) (Stop, error) {
+	return d.run(ctx, func() bool { return false })
+}
+
+// run steps until done returns true, a breakpoint is reached, the program
+// ends or an error occurs.
+func (d *Debugger) run(ctx context.Context, done func() bool) (Stop, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Paused, err
+		}
+		stop, err := d.Step()
+		if err != nil || stop != Paused || done() {
+			return stop, err
+		}
+		if d.breakpoints[d.rt.PC] {
+			return Breakpoint, nil
+		}
+	}
+}
+
+// Command evaluates a text command, writing its output to w.
+func (d *Debugger) Command(ctx context.Context, w io.Writer, line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	name, args := fields[0], fields[1:]
+	switch name {
+	case "break", "b", "delete", "d":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s location", name)
+		}
+		if name == "delete" || name == "d" {
+			return d.Delete(args[0])
+		}
+		pc, err := d.Break(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "breakpoint at %s\n", d.position(pc))
+	case "breakpoints":
+		for _, pc := range d.Breakpoints() {
+			fmt.Fprintf(w, "%s: %s\n", d.position(pc), d.format(pc))
+		}
+	case "step", "s":
+		n := 1
+		if len(args) == 1 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("bad step count %s", args[0])
+			}
+		} else if len(args) != 0 {
+			return fmt.Errorf("usage: %s [n]", name)
+		}
+		stop, err := Paused, error(nil)
+		for i := 0; i < n && stop == Paused && err == nil; i++ {
+			stop, err = d.Step()
+		}
+		return d.report(w, stop, err)
+	case "next", "n":
+		stop, err := d.Next(ctx)
+		return d.report(w, stop, err)
+	case "continue", "c":
+		stop, err := d.Continue(ctx)
+		return d.report(w, stop, err)
+	case "restart", "r":
+		d.Restart()
+		return d.report(w, Paused, nil)
+	case "where", "w":
+		fmt.Fprintf(w, "at %s: %s\n", d.position(d.rt.PC), d.format(d.rt.PC))
+		for i := len(d.rt.CallStack) - 1; 0 <= i; i-- {
+			fmt.Fprintf(w, "called from %s\n", d.position(d.rt.CallStack[i]-1))
+		}
+	case "list", "l":
+		for pc := d.rt.PC - 5; pc <= d.rt.PC+5; pc++ {
+			if pc < 0 || len(d.mod.Code) <= pc {
+				continue
+			}
+			mark := "  "
+			if pc == d.rt.PC {
+				mark = "=>"
+			}
+			if d.breakpoints[pc] {
+				mark = mark[:1] + "*"
+			}
+			fmt.Fprintf(w, "%s %5d  %s\n", mark, pc, d.format(pc))
+		}
+	case "help", "h":
+		fmt.Fprint(w, help)
+	case "stack":
+		printValues(w, &d.rt, d.rt.Stack)
+	case "log":
+		printValues(w, &d.rt, d.rt.Log)
+	default:
+		return fmt.Errorf("unknown command %s", name)
+	}
+	return nil
+}
+
+const help = `break loc     (b)  set a breakpoint
+delete loc    (d)  delete a breakpoint
+breakpoints        list the breakpoints
+step [n]      (s)  evaluate n operations, default 1
+next          (n)  evaluate an operation, stepping over calls
+continue      (c)  run until a breakpoint, solution or error
+restart       (r)  start the program again
+where         (w)  show the current position and call stack
+list          (l)  show the code around the current position
+stack, log         show the stack or log
+help          (h)  show this help
+`
+
+// report writes why execution stopped and where.
+func (d *Debugger) report(w io.Writer, stop Stop, err error) error {
+	if err != nil {
+		return err
+	}
+	switch stop {
+	case Solution:
+		fmt.Fprintln(w, "solution:")
+		fmt.Fprintln(w, "stack:")
+		printValues(w, &d.rt, d.rt.Stack)
+		fmt.Fprintln(w, "log:")
+		printValues(w, &d.rt, d.rt.Log)
+		return nil
+	case Done:
+		fmt.Fprintln(w, "no more solutions")
+		return nil
+	case Breakpoint:
+		fmt.Fprint(w, "breakpoint ")
+	}
+	fmt.Fprintf(w, "at %s: %s\n", d.position(d.rt.PC), d.format(d.rt.PC))
+	return nil
+}
+
+// position describes pc, naming the closest label before it and the source
+// position, if known.
+func (d *Debugger) position(pc int) string {
+	s := fmt.Sprintf("pc %d", pc)
+	for l := pc; 0 <= l && l < len(d.mod.Code); l-- {
+		if name, ok := d.targets[int32(l)]; ok {
+			if l == pc {
+				s += fmt.Sprintf(" (%s)", name)
+			} else {
+				s += fmt.Sprintf(" (%s+%d)", name, pc-l)
+			}
+			break
+		}
+	}
+	if pos, ok := d.src.Pos(pc); ok {
+		s += fmt.Sprintf(" at %v", pos)
+	}
+	return s
+}
+
+// format returns the assembly for the operation at pc.
+func (d *Debugger) format(pc int) string {
+	if pc < 0 || len(d.mod.Code) <= pc {
+		return "end"
+	}
+	return asm.Format(d.mod.Symbols, d.targets, d.mod.Code[pc])
+}
+
+func printValues(w io.Writer, rt *runtime.Runtime, values []runtime.Value) {
+	for _, v := range values {
+		fmt.Fprintf(w, "\t%s\n", rt.Format(v))
+	}
+}
@@ -0,0 +1,207 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hjfreyer/stalog/asm"
+	"github.com/hjfreyer/stalog/compiler"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+)
+
+const src = `package foo
+symbol A
+symbol B
+	call main
+	jump end
+main:
+	choice other
+	push A
+	call twice
+	return
+other:
+	push B
+	call twice
+	return
+twice:
+	dup
+	group 2
+	return
+end:
+`
+
+func load(t *testing.T) *Debugger {
+	mod, err := asm.Assemble("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(mod)
+}
+
+func TestBreak(t *testing.T) {
+	d := load(t)
+	var tcs = []struct {
+		loc     string
+		pc      int
+		wantErr string
+	}{
+		{loc: "3", pc: 3},
+		{loc: "twice", pc: 11},
+		{loc: "17", wantErr: "position 17 outside the code"},
+		{loc: "nowhere", wantErr: "no label nowhere"},
+		{loc: "foo.slm:3", wantErr: "module has no line information"},
+	}
+	for _, tc := range tcs {
+		pc, err := d.Break(tc.loc)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("%s: got error %v; wanted %s", tc.loc, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.loc, err)
+		} else if pc != tc.pc {
+			t.Errorf("%s: got pc %d; wanted %d", tc.loc, pc, tc.pc)
+		}
+	}
+	if got, want := d.Breakpoints(), []int{3, 11}; !equal(got, want) {
+		t.Errorf("got breakpoints %v; wanted %v", got, want)
+	}
+	if err := d.Delete("3"); err != nil {
+		t.Error(err)
+	}
+	if err := d.Delete("3"); err == nil {
+		t.Error("expected error deleting missing breakpoint")
+	}
+}
+
+func equal(x, y []int) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRun(t *testing.T) {
+	d := load(t)
+	ctx := context.Background()
+	type step struct {
+		do   func() (Stop, error)
+		stop Stop
+		pc   int
+	}
+	steps := []step{
+		{d.Step, Paused, 2},
+		{d.Step, Paused, 3},
+		{func() (Stop, error) { return d.Next(ctx) }, Paused, 4},
+		{func() (Stop, error) { return d.Next(ctx) }, Paused, 5},
+		{func() (Stop, error) { return d.Next(ctx) }, Paused, 6},
+		{func() (Stop, error) { return d.Continue(ctx) }, Solution, 16},
+		{func() (Stop, error) { d.Break("twice"); return d.Continue(ctx) }, Breakpoint, 11},
+		{func() (Stop, error) { return d.Continue(ctx) }, Solution, 16},
+		{func() (Stop, error) { return d.Continue(ctx) }, Done, 16},
+		{func() (Stop, error) { d.Restart(); return d.Continue(ctx) }, Breakpoint, 11},
+	}
+	for i, s := range steps {
+		stop, err := s.do()
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if stop != s.stop || d.Runtime().PC != s.pc {
+			t.Errorf("step %d: stopped with %v at pc %d; wanted %v at pc %d", i, stop, d.Runtime().PC, s.stop, s.pc)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	d := load(t)
+	var tcs = []struct {
+		cmd  string
+		want string
+	}{
+		{"b twice", "breakpoint at pc 11 (twice)\n"},
+		{"c", "breakpoint at pc 11 (twice): twice:\n"},
+		{"s 2", "at pc 13 (twice+2): group 2\n"},
+		{"stack", "\tA\n\tA\n"},
+		{"w", "at pc 13 (twice+2): group 2\ncalled from pc 5 (main+3)\ncalled from pc 0\n"},
+		{"l", `       8  push B
+       9  call twice
+      10  return
+ *    11  twice:
+      12  dup
+=>    13  group 2
+      14  return
+      15  end:
+`},
+		{"c", "solution:\nstack:\n\t(A A)\nlog:\n"},
+		{"r", "at pc 0: call main\n"},
+	}
+	for _, tc := range tcs {
+		var b bytes.Buffer
+		if err := d.Command(context.Background(), &b, tc.cmd); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.cmd, err)
+			continue
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("%s: got:\n%s\nwanted:\n%s", tc.cmd, got, tc.want)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	mod, err := compiler.Compile("foo.slm", `package foo
+symbol Z
+symbol S
+def main =
+  Z succ .
+def succ =
+  S
+  Z .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := New(mod)
+	if _, err := d.Break("foo.slm:2"); err == nil || err.Error() != "no code at foo.slm:2" {
+		t.Errorf("got error %v; wanted no code at foo.slm:2", err)
+	}
+	var tcs = []struct {
+		cmd  string
+		want string
+	}{
+		{"b foo.slm:7", "breakpoint at pc 7 (succ+1) at foo.slm:7:3\n"},
+		{"c", "breakpoint at pc 7 (succ+1) at foo.slm:7:3: push S\n"},
+		{"w", "at pc 7 (succ+1) at foo.slm:7:3: push S\ncalled from pc 4 (main+2) at foo.slm:5:5\ncalled from pc 0 at foo.slm:1:1\n"},
+	}
+	for _, tc := range tcs {
+		var b bytes.Buffer
+		if err := d.Command(context.Background(), &b, tc.cmd); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.cmd, err)
+			continue
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("%s: got:\n%s\nwanted:\n%s", tc.cmd, got, tc.want)
+		}
+	}
+}
+
+func TestBadTarget(t *testing.T) {
+	jump := &pb.Operation{Op: &pb.Operation_Jump{Jump: &pb.Jump{Target: 5}}}
+	d := New(&pb.Module{Code: []*pb.Operation{jump}})
+	stop, err := d.Step()
+	e, ok := err.(*runtime.EvalError)
+	if !ok || e.Kind != runtime.BadTarget || e.PC != 0 {
+		t.Errorf("got error %v; wanted BadTarget at pc 0", err)
+	}
+	if stop != Paused || d.Runtime().PC != 0 {
+		t.Errorf("stopped with %v at pc %d; wanted %v at pc 0", stop, d.Runtime().PC, Paused)
+	}
+}
@@ -0,0 +1,234 @@
+// Package diff computes line-based differences between files and writes
+// them in the unified format read by patch.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// Unified returns a unified diff from a to b, which are files named aName
+// and bName, or nil if they're equal. Lines are compared exactly, including
+// a missing newline at the end of a file.
+//
+//	--- nat.slm.orig
+//	+++ nat.slm
+//	@@ -2,3 +2,3 @@
+//	 symbol Z
+//	-symbol  S
+//	+symbol S
+//	 nat(Z).
+func Unified(aName, bName string, a, b []byte) []byte {
+	x, y := lines(a), lines(b)
+	ops := edits(x, y)
+	var out bytes.Buffer
+	for i := 0; i < len(ops); {
+		// Find the next change and extend its hunk over every change no
+		// more than 2*context unchanged lines after the previous one.
+		for i < len(ops) && ops[i].kind == keep {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for i < len(ops) {
+			if ops[i].kind != keep {
+				end = i + 1
+				i++
+				continue
+			}
+			run := i
+			for run < len(ops) && ops[run].kind == keep {
+				run++
+			}
+			if run == len(ops) || 2*context < run-i {
+				break
+			}
+			i = run
+		}
+		if end += context; len(ops) < end {
+			end = len(ops)
+		}
+		i = end
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+		hunk := ops[start:end]
+		var na, nb int
+		for _, op := range hunk {
+			if op.kind != add {
+				na++
+			}
+			if op.kind != remove {
+				nb++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", span(hunk[0].a, na), span(hunk[0].b, nb))
+		for _, op := range hunk {
+			var line string
+			switch op.kind {
+			case keep:
+				line = " " + x[op.a]
+			case remove:
+				line = "-" + x[op.a]
+			case add:
+				line = "+" + y[op.b]
+			}
+			out.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	return out.Bytes()
+}
+
+// span formats the range of n lines starting at index start for a hunk
+// header, as diff does: the line number alone for one line, and the number
+// of the line before for none.
+func span(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// lines splits text after each newline.
+func lines(text []byte) []string {
+	var ls []string
+	for len(text) != 0 {
+		i := bytes.IndexByte(text, '\n') + 1
+		if i == 0 {
+			i = len(text)
+		}
+		ls = append(ls, string(text[:i]))
+		text = text[i:]
+	}
+	return ls
+}
+
+type kind int
+
+const (
+	keep kind = iota
+	remove
+	add
+)
+
+// edit is a step of an edit script turning one list of lines into another.
+// a and b are the indices in each list of the lines the step is at.
+type edit struct {
+	kind kind
+	a, b int
+}
+
+// edits returns a shortest edit script turning x into y.
+func edits(x, y []string) []edit {
+	// Lines the lists start and end with are kept, which saves work for
+	// the usual diff of a few changes.
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+	var ops []edit
+	for i := 0; i < pre; i++ {
+		ops = append(ops, edit{keep, i, i})
+	}
+	for _, op := range myers(x[pre:len(x)-suf], y[pre:len(y)-suf]) {
+		ops = append(ops, edit{op.kind, op.a + pre, op.b + pre})
+	}
+	for i := suf; 0 < i; i-- {
+		ops = append(ops, edit{keep, len(x) - i, len(y) - i})
+	}
+	return ops
+}
+
+// myers returns a shortest edit script turning x into y, found by Myers'
+// algorithm, "An O(ND) Difference Algorithm and Its Variations".
+func myers(x, y []string) []edit {
+	n, m := len(x), len(y)
+	off := n + m
+	// v[off+k] holds the furthest index into x reached on diagonal k, and
+	// trace the values of v before each round, for finding the path back.
+	v := make([]int, 2*off+2)
+	var trace [][]int
+	for d := 0; d <= off; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				i = v[off+k+1]
+			} else {
+				i = v[off+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[off+k] = i
+			if n <= i && m <= j {
+				return backtrack(trace, off, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack follows the path found by myers back from (n, m) to the start,
+// returning the edits along it.
+func backtrack(trace [][]int, off, n, m int) []edit {
+	var ops []edit
+	i, j := n, m
+	for d := len(trace) - 1; 0 <= d; d-- {
+		v := trace[d]
+		k := i - j
+		var prev int
+		if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+			prev = k + 1
+		} else {
+			prev = k - 1
+		}
+		pi := v[off+prev]
+		pj := pi - prev
+		for pi < i && pj < j {
+			i--
+			j--
+			ops = append(ops, edit{keep, i, j})
+		}
+		if d == 0 {
+			break
+		}
+		if i == pi {
+			j--
+			ops = append(ops, edit{add, i, j})
+		} else {
+			i--
+			ops = append(ops, edit{remove, i, j})
+		}
+	}
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
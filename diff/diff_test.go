@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	numbers := func(n int, change func(i int) string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			b.WriteString(change(i))
+		}
+		return b.String()
+	}
+	var tcs = []struct {
+		name string
+		a, b string
+		want string
+	}{{
+		name: "equal",
+		a:    "a\nb\n",
+		b:    "a\nb\n",
+	}, {
+		name: "change",
+		a:    "package nat\n\nsymbol Z\nsymbol  S\n\nnat(Z).\nnat(S(X)) :- nat(X).\n",
+		b:    "package nat\n\nsymbol Z\nsymbol S\n\nnat(Z).\nnat(S(X)) :- nat(X).\n",
+		want: `--- a
++++ b
+@@ -1,7 +1,7 @@
+ package nat
+ 
+ symbol Z
+-symbol  S
++symbol S
+ 
+ nat(Z).
+ nat(S(X)) :- nat(X).
+`,
+	}, {
+		name: "hunks",
+		a: numbers(20, func(i int) string {
+			if i == 20 {
+				return "20"
+			}
+			return fmt.Sprintf("%d\n", i)
+		}),
+		b: "new\n" + numbers(20, func(i int) string {
+			if i == 6 {
+				return "six\n"
+			}
+			return fmt.Sprintf("%d\n", i)
+		}),
+		want: `--- a
++++ b
+@@ -1,9 +1,10 @@
++new
+ 1
+ 2
+ 3
+ 4
+ 5
+-6
++six
+ 7
+ 8
+ 9
+@@ -17,4 +18,4 @@
+ 17
+ 18
+ 19
+-20
+\ No newline at end of file
++20
+`,
+	}, {
+		name: "delete all",
+		a:    "x\n",
+		want: `--- a
++++ b
+@@ -1 +0,0 @@
+-x
+`,
+	}}
+	for _, tc := range tcs {
+		if got := string(Unified("a", "b", []byte(tc.a), []byte(tc.b))); got != tc.want {
+			t.Errorf("%s: got:\n%s\nwanted:\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestPatch checks that applying the diffs of random files to the first
+// file yields the second, and that they're as short as they can be.
+func TestPatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		var ls []string
+		for n := r.Intn(20); 0 < n; n-- {
+			ls = append(ls, fmt.Sprintf("%d\n", r.Intn(5)))
+		}
+		return ls
+	}
+	for i := 0; i < 1000; i++ {
+		x, y := gen(), gen()
+		ops := edits(x, y)
+		var got []string
+		changes := 0
+		for _, op := range ops {
+			switch op.kind {
+			case keep:
+				got = append(got, x[op.a])
+			case add:
+				got = append(got, y[op.b])
+			}
+			if op.kind != keep {
+				changes++
+			}
+		}
+		if strings.Join(got, "") != strings.Join(y, "") {
+			t.Fatalf("edits of %q to %q give %q", x, y, got)
+		}
+		if want := len(x) + len(y) - 2*lcs(x, y); changes != want {
+			t.Fatalf("edits of %q to %q make %d changes; wanted %d", x, y, changes, want)
+		}
+		if d := Unified("a", "b", []byte(strings.Join(x, "")), []byte(strings.Join(y, ""))); (d == nil) != (changes == 0) || d != nil && !bytes.HasPrefix(d, []byte("--- a\n+++ b\n@@ ")) {
+			t.Fatalf("bad diff of %q to %q:\n%s", x, y, d)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of x and y.
+func lcs(x, y []string) int {
+	l := make([][]int, len(x)+1)
+	for i := range l {
+		l[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; 0 <= i; i-- {
+		for j := len(y) - 1; 0 <= j; j-- {
+			switch {
+			case x[i] == y[j]:
+				l[i][j] = l[i+1][j+1] + 1
+			case l[i+1][j] < l[i][j+1]:
+				l[i][j] = l[i][j+1]
+			default:
+				l[i][j] = l[i+1][j]
+			}
+		}
+	}
+	return l[0][0]
+}
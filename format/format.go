@@ -0,0 +1,247 @@
+// Package format prints Stalog source in a canonical style.
+//
+// Formatted source has the package clause first, then the imports, sorted by
+// path, then the symbol declarations, then the defs and relations. The
+// clauses of each relation are gathered together where its first clause
+// was. Since symbols and clauses keep their relative order, formatting
+// doesn't change what a file compiles to.
+//
+// Each definition is printed on a single line, with single spaces between
+// words and after commas, and a blank line between one def or relation and
+// the next. Comments stay with the definition they are in, precede or follow
+// on the same line.
+package format
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/hjfreyer/stalog/ast"
+	"github.com/hjfreyer/stalog/parser"
+)
+
+// Source formats src, the Stalog source file named filename. If src doesn't
+// parse, the error is a parser.ErrorList.
+func Source(filename string, src []byte) ([]byte, error) {
+	m, err := parser.ParseFile(filename, string(src))
+	if err != nil {
+		return nil, err
+	}
+	text := []rune(string(src))
+	items := collect(m)
+	footer := attach(text, items, m.Comments)
+
+	var buf bytes.Buffer
+	var prev *item
+	for _, it := range order(items) {
+		if prev != nil && (prev.section != it.section || it.section == defSection && prev.name != it.name) {
+			buf.WriteByte('\n')
+		}
+		for i, c := range it.leading {
+			buf.WriteString(c.Text + "\n")
+			next := it.pos.Line
+			if i+1 < len(it.leading) {
+				next = it.leading[i+1].Pos.Line
+			}
+			if c.Pos.Line+1 < next {
+				buf.WriteByte('\n')
+			}
+		}
+		buf.WriteString(it.text)
+		if it.trailing != nil {
+			buf.WriteString(" " + it.trailing.Text)
+		}
+		buf.WriteByte('\n')
+		prev = it
+	}
+	if len(footer) != 0 {
+		buf.WriteByte('\n')
+	}
+	for _, c := range footer {
+		buf.WriteString(c.Text + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// Sections of a formatted file, in order.
+const (
+	packageSection = iota
+	importSection
+	symbolSection
+	defSection
+)
+
+// item is the package clause, an import or a definition.
+type item struct {
+	section int
+	// name is the name of a def or of the relation a clause is part of.
+	name string
+	pos  ast.Pos
+	// end is the offset just past the item's last token.
+	end  int
+	text string
+
+	leading  []*ast.Comment
+	trailing *ast.Comment
+}
+
+// collect returns the items of m in source order.
+func collect(m *ast.Module) []*item {
+	items := []*item{{
+		section: packageSection,
+		pos:     m.PackagePos,
+		text:    "package " + m.Package,
+	}}
+	for _, imp := range m.Imports {
+		items = append(items, &item{
+			section: importSection,
+			pos:     imp.Pos,
+			text:    `import "` + imp.Path + `"`,
+		})
+	}
+	for _, def := range m.Defs {
+		it := &item{
+			section: defSection,
+			pos:     def.Position(),
+		}
+		switch d := def.(type) {
+		case *ast.SymbolDef:
+			it.section = symbolSection
+			it.text = "symbol " + d.Name
+		case *ast.CodeDef:
+			it.name = d.Name
+			words := []string{"def", d.Name, "="}
+			for _, w := range d.Words {
+				words = append(words, ast.QualifiedName(w.Package, w.Name))
+			}
+			it.text = strings.Join(append(words, "."), " ")
+		case *ast.Clause:
+			it.name = d.Head.Name
+			it.text = goal(d.Head)
+			if len(d.Body) != 0 {
+				var goals []string
+				for _, g := range d.Body {
+					goals = append(goals, goal(g))
+				}
+				it.text += " :- " + strings.Join(goals, ", ")
+			}
+			it.text += "."
+		}
+		items = append(items, it)
+	}
+	return items
+}
+
+func goal(g *ast.Goal) string {
+	return ast.QualifiedName(g.Package, g.Name) + args(g.Args)
+}
+
+func args(terms []*ast.Term) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	var s []string
+	for _, t := range terms {
+		s = append(s, ast.QualifiedName(t.Package, t.Name)+args(t.Args))
+	}
+	return "(" + strings.Join(s, ", ") + ")"
+}
+
+// attach assigns each comment to an item. A comment following an item on the
+// line the item ends on trails that item; any other comment leads the item
+// it's in or precedes. It returns the comments after the last item.
+func attach(text []rune, items []*item, comments []*ast.Comment) []*ast.Comment {
+	for i, it := range items {
+		next := len(text)
+		if i+1 < len(items) {
+			next = items[i+1].pos.Offset
+		}
+		it.end = lastToken(text, it.pos.Offset, next)
+	}
+	var footer []*ast.Comment
+	for _, c := range comments {
+		i := sort.Search(len(items), func(i int) bool {
+			return c.Pos.Offset < items[i].end
+		})
+		inside := i < len(items) && items[i].pos.Offset < c.Pos.Offset
+		if 0 < i && !inside && line(text, items[i-1].end) == c.Pos.Line {
+			items[i-1].trailing = c
+		} else if i < len(items) {
+			items[i].leading = append(items[i].leading, c)
+		} else {
+			footer = append(footer, c)
+		}
+	}
+	return footer
+}
+
+// lastToken returns the offset just past the last character of text between
+// from and to which isn't a space or in a comment.
+func lastToken(text []rune, from, to int) int {
+	end := from
+	comment, quoted := false, false
+	for i := from; i < to; i++ {
+		c := text[i]
+		switch {
+		case comment:
+			comment = c != '\n'
+			continue
+		case quoted:
+			quoted = c != '"'
+		case c == '#':
+			comment = true
+			continue
+		case c == '"':
+			quoted = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// line returns the line of the character before offset in text.
+func line(text []rune, offset int) int {
+	n := 1
+	for _, c := range text[:offset-1] {
+		if c == '\n' {
+			n++
+		}
+	}
+	return n
+}
+
+// order returns items in the order they are printed.
+func order(items []*item) []*item {
+	var sorted []*item
+	// Gather each relation's clauses at its first clause.
+	defs := map[string][]*item{}
+	for _, it := range items {
+		if it.section != defSection {
+			sorted = append(sorted, it)
+			continue
+		}
+		if _, ok := defs[it.name]; !ok {
+			sorted = append(sorted, it)
+		}
+		defs[it.name] = append(defs[it.name], it)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.section != b.section {
+			return a.section < b.section
+		}
+		return a.section == importSection && a.text < b.text
+	})
+	var printed []*item
+	for _, it := range sorted {
+		if it.section == defSection {
+			printed = append(printed, defs[it.name]...)
+		} else {
+			printed = append(printed, it)
+		}
+	}
+	return printed
+}
@@ -0,0 +1,133 @@
+package format
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"package only", "package   foo", "package foo\n"},
+		{"spacing", `
+# Check
+package   foo
+
+symbol Z
+      symbol S
+def  main=Z   S .
+nat( S(X) ) :-nat(X) ,nat:nat( X ) .
+`, `# Check
+package foo
+
+symbol Z
+symbol S
+
+def main = Z S .
+
+nat(S(X)) :- nat(X), nat:nat(X).
+`},
+		{"ordering", `package foo
+import "b"
+import "a"
+nat(Z).
+symbol Z
+def main = .
+add(Z).
+nat(S(X)) :- nat(X).
+symbol S
+`, `package foo
+
+import "a"
+import "b"
+
+symbol Z
+symbol S
+
+nat(Z).
+nat(S(X)) :- nat(X).
+
+def main = .
+
+add(Z).
+`},
+		{"comments", `# Header.
+
+# About foo.
+package foo # Trailing package.
+# Before Z.
+symbol Z # After Z.
+
+# Section.
+
+# nat holds for naturals.
+nat(S(X)) :- # Inside.
+	nat(X). # After.
+# Footer.
+`, `# Header.
+
+# About foo.
+package foo # Trailing package.
+
+# Before Z.
+symbol Z # After Z.
+
+# Section.
+
+# nat holds for naturals.
+# Inside.
+nat(S(X)) :- nat(X). # After.
+
+# Footer.
+`},
+		{"comment in def", "package foo def main = # Words.\n  Z # More.\n  Y .\n", `package foo
+
+# Words.
+# More.
+def main = Z Y .
+`},
+	}
+
+	for _, tc := range tcs {
+		got, err := Source("", []byte(tc.src))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s: wrong output. Got:\n%s\nwanted:\n%s", tc.name, got, tc.want)
+			continue
+		}
+		again, err := Source("", got)
+		if err != nil {
+			t.Errorf("%s: formatted source doesn't parse: %v", tc.name, err)
+			continue
+		}
+		if string(again) != string(got) {
+			t.Errorf("%s: formatting isn't idempotent. Got:\n%s", tc.name, again)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	if _, err := Source("", []byte("package foo symbol z")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestExampleFormatted(t *testing.T) {
+	src, err := ioutil.ReadFile("../examples/nat.slm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Source("nat.slm", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(src) {
+		t.Errorf("example isn't formatted. Formatted:\n%s", got)
+	}
+}
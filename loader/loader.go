@@ -0,0 +1,241 @@
+// Package loader finds, parses and checks the source files of a Stalog
+// package and of the packages it imports.
+//
+// A package is a directory of .slm source files which all declare the same
+// package name. The package imported by
+//
+//	import "path/to/nat"
+//
+// is the directory path/to/nat under the first directory in the search path
+// which has any source files there, and must be named nat. Its names are
+// referred to by qualifying them with the package name, as in nat:Z.
+package loader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hjfreyer/stalog/ast"
+	"github.com/hjfreyer/stalog/check"
+	"github.com/hjfreyer/stalog/parser"
+)
+
+// Config configures how packages are found.
+type Config struct {
+	// Path lists the directories searched, in order, for imported packages.
+	Path []string
+}
+
+// Package is a loaded package.
+type Package struct {
+	// Path is the import path of the package, or empty for the package
+	// being loaded.
+	Path string
+	Name string
+	// Dir is the directory holding the package's files, if it was imported.
+	Dir   string
+	Files []*ast.Module
+	// Imports maps the names of the packages imported by the package's files
+	// to those packages.
+	Imports map[string]*Package
+	Decls   *check.Decls
+	// Warnings lists problems with the package's files which don't stop it
+	// loading, as found by check.Singletons.
+	Warnings check.ErrorList
+}
+
+// Program is a package along with all the packages it imports, directly or
+// indirectly.
+type Program struct {
+	// Main is the package which was loaded.
+	Main *Package
+	// Packages lists every package in the program, each after the packages
+	// it imports, so Main is last.
+	Packages []*Package
+}
+
+// Error is an error finding an imported package.
+type Error struct {
+	Pos ast.Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Pos, e.Msg)
+}
+
+// ErrorList is a list of errors loading a program. Its elements are
+// *parser.Error, *check.Error, *Error or errors reading files.
+type ErrorList []error
+
+// Error returns the messages of the errors, one after another.
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// LoadFiles loads the package made up of the named source files, and every
+// package it imports. If any package fails to load, the error is an
+// ErrorList.
+func (c *Config) LoadFiles(filenames ...string) (*Program, error) {
+	l := loader{
+		config:   c,
+		packages: map[string]*Package{},
+		names:    map[string]string{},
+	}
+	main := l.load("", filenames)
+	if len(l.errs) != 0 {
+		return nil, l.errs
+	}
+	return &Program{Main: main, Packages: l.order}, nil
+}
+
+type loader struct {
+	config *Config
+	// packages maps the import paths of the packages loaded so far to the
+	// packages, or to nil for ones which failed to load.
+	packages map[string]*Package
+	// names maps the names of imported packages to their import paths.
+	names map[string]string
+	// stack lists the import paths of the packages being loaded, outermost
+	// first.
+	stack []string
+	order []*Package
+	errs  ErrorList
+}
+
+func (l *loader) errorf(pos ast.Pos, format string, args ...interface{}) {
+	l.errs = append(l.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// addErr records err, flattening lists of errors.
+func (l *loader) addErr(err error) {
+	switch err := err.(type) {
+	case parser.ErrorList:
+		for _, e := range err {
+			l.errs = append(l.errs, e)
+		}
+	case check.ErrorList:
+		for _, e := range err {
+			l.errs = append(l.errs, e)
+		}
+	default:
+		l.errs = append(l.errs, err)
+	}
+}
+
+// load loads the package with the given import path made up of files, after
+// loading the packages it imports. It returns nil if the package or any of
+// its imports has errors.
+func (l *loader) load(importPath string, files []string) *Package {
+	errs := len(l.errs)
+	pkg := &Package{
+		Path:    importPath,
+		Imports: map[string]*Package{},
+	}
+	for _, filename := range files {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			l.addErr(err)
+			continue
+		}
+		m, err := parser.ParseFile(filename, string(src))
+		if err != nil {
+			l.addErr(err)
+			continue
+		}
+		pkg.Files = append(pkg.Files, m)
+	}
+	if len(l.errs) != errs {
+		return nil
+	}
+	if len(pkg.Files) == 0 {
+		l.addErr(fmt.Errorf("no source files"))
+		return nil
+	}
+	pkg.Name = pkg.Files[0].Package
+
+	imports := map[string]*check.Decls{}
+	for _, m := range pkg.Files {
+		for _, imp := range m.Imports {
+			if dep := l.importPackage(imp); dep != nil {
+				pkg.Imports[imp.Name()] = dep
+				imports[imp.Name()] = dep.Decls
+			}
+		}
+	}
+	if len(l.errs) != errs {
+		return nil
+	}
+	decls, err := check.Package(pkg.Files, imports)
+	if err != nil {
+		l.addErr(err)
+		return nil
+	}
+	pkg.Decls = decls
+	pkg.Warnings = check.Singletons(pkg.Files, decls)
+	l.order = append(l.order, pkg)
+	return pkg
+}
+
+// importPackage finds and loads the package imported by imp.
+func (l *loader) importPackage(imp *ast.Import) *Package {
+	p := imp.Path
+	if path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+		l.errorf(imp.Pos, "invalid import path %q", p)
+		return nil
+	}
+	for i, q := range l.stack {
+		if q == p {
+			cycle := append(append([]string(nil), l.stack[i:]...), p)
+			l.errorf(imp.Pos, "import cycle: %s", strings.Join(cycle, " imports "))
+			return nil
+		}
+	}
+	if prev, ok := l.names[imp.Name()]; ok && prev != p {
+		l.errorf(imp.Pos, "packages %q and %q are both named %s", prev, p, imp.Name())
+		return nil
+	}
+	if pkg, ok := l.packages[p]; ok {
+		return pkg
+	}
+
+	dir, files := l.find(p)
+	if files == nil {
+		l.errorf(imp.Pos, "cannot find package %q in any of %s", p, strings.Join(l.config.Path, ", "))
+		l.packages[p] = nil
+		return nil
+	}
+	l.stack = append(l.stack, p)
+	pkg := l.load(p, files)
+	l.stack = l.stack[:len(l.stack)-1]
+	if pkg != nil && pkg.Name != imp.Name() {
+		l.errorf(imp.Pos, "%s holds package %s; expected package %s", dir, pkg.Name, imp.Name())
+		pkg = nil
+	}
+	if pkg != nil {
+		pkg.Dir = dir
+	}
+	l.packages[p] = pkg
+	l.names[imp.Name()] = p
+	return pkg
+}
+
+// find returns the directory holding the package with the given import path
+// and its source files.
+func (l *loader) find(importPath string) (string, []string) {
+	for _, root := range l.config.Path {
+		dir := filepath.Join(root, filepath.FromSlash(importPath))
+		files, _ := filepath.Glob(filepath.Join(dir, "*.slm"))
+		if len(files) != 0 {
+			return dir, files
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,129 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files, which maps slash-separated paths to contents,
+// under a new temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "loader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.slm":            `package main import "lib/nat" import "two" def main = two:two .`,
+		"lib/nat/nat.slm":     "package nat symbol Z symbol S nat(Z).",
+		"lib/nat/succ.slm":    "package nat def succ = S .",
+		"other/two/two.slm":   `package two import "lib/nat" def two = nat:Z nat:succ nat:succ .`,
+		"other/lib/nat/x.slm": "package nat symbol Shadowed",
+	})
+	defer os.RemoveAll(dir)
+
+	config := Config{Path: []string{dir, filepath.Join(dir, "other")}}
+	prog, err := config.LoadFiles(filepath.Join(dir, "main.slm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, pkg := range prog.Packages {
+		got = append(got, pkg.Path+"="+pkg.Name)
+	}
+	if want := []string{"lib/nat=nat", "two=two", "=main"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong packages. Got %v; wanted %v", got, want)
+	}
+	if prog.Main != prog.Packages[2] {
+		t.Errorf("Main isn't the last package")
+	}
+	nat := prog.Packages[0]
+	if len(nat.Files) != 2 || nat.Dir != filepath.Join(dir, "lib", "nat") {
+		t.Errorf("wrong files for nat: %d files in %s", len(nat.Files), nat.Dir)
+	}
+	if _, ok := nat.Decls.Defs["succ"]; !ok {
+		t.Errorf("succ not declared in nat")
+	}
+	if prog.Main.Imports["nat"] != nat || prog.Packages[1].Imports["nat"] != nat {
+		t.Errorf("nat not shared between importers")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	var tcs = []struct {
+		name  string
+		files map[string]string
+		// want holds substrings of the expected errors.
+		want []string
+	}{
+		{"missing package", map[string]string{
+			"main.slm": `package main import "nope"`,
+		}, []string{`main.slm:1:14: cannot find package "nope"`}},
+		{"invalid path", map[string]string{
+			"main.slm": `package main import "../nope" import "/abs" import "a//b"`,
+		}, []string{
+			`main.slm:1:14: invalid import path "../nope"`,
+			`main.slm:1:31: invalid import path "/abs"`,
+			`main.slm:1:45: invalid import path "a//b"`,
+		}},
+		{"wrong name", map[string]string{
+			"main.slm":    `package main import "nat"`,
+			"nat/nat.slm": "package int",
+		}, []string{"main.slm:1:14: ", "holds package int; expected package nat"}},
+		{"cycle", map[string]string{
+			"main.slm": `package main import "a"`,
+			"a/a.slm":  `package a import "b"`,
+			"b/b.slm":  `package b import "a"`,
+		}, []string{"b.slm:1:11: import cycle: a imports b imports a"}},
+		{"same name", map[string]string{
+			"main.slm":    `package main import "x/nat" import "y/nat"`,
+			"x/nat/n.slm": "package nat",
+			"y/nat/n.slm": "package nat",
+		}, []string{`packages "x/nat" and "y/nat" are both named nat`}},
+		{"errors in import", map[string]string{
+			"main.slm":  `package main import "nat" def main = nat:Z .`,
+			"nat/a.slm": "package nat symbol z",
+			"nat/b.slm": "package nat def main = Y .",
+		}, []string{
+			// b.slm isn't checked, since nat failed to parse.
+			"a.slm:1:20: unexpected \"z\"",
+		}},
+	}
+
+	for _, tc := range tcs {
+		dir := writeFiles(t, tc.files)
+		config := Config{Path: []string{dir}}
+		_, err := config.LoadFiles(filepath.Join(dir, "main.slm"))
+		os.RemoveAll(dir)
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("%s: got error %v; wanted an ErrorList", tc.name, err)
+			continue
+		}
+		msg := err.Error()
+		for _, want := range tc.want {
+			if !strings.Contains(msg, want) {
+				t.Errorf("%s: error doesn't contain %q:\n%s", tc.name, want, msg)
+			}
+		}
+		if len(errs) < 1 {
+			t.Errorf("%s: empty ErrorList", tc.name)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package optimize rewrites bytecode into equivalent, shorter bytecode.
+//
+// The optimizer makes a peephole pass over the code: adjacent Permutes are
+// composed into one, Permutes which leave the stack as it was are removed,
+// and a Push followed by a Permute popping just the pushed value cancels
+// out. Operations which are the targets of control flow are never combined
+// with the operations before them, and targets are updated to the new
+// positions of the operations they refer to.
+//
+// The optimized code has the same results as the original, but it may not
+// fail a runtime check, such as for stack underflow, where the original would
+// have. Code accepted by package verify behaves identically.
+package optimize
+
+import (
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Module returns a copy of mod with its code optimized. The positions in its
+// debug info are updated to match, with combined operations taking the
+// position of the first.
+func Module(mod *pb.Module) *pb.Module {
+	opt := proto.Clone(mod).(*pb.Module)
+	var positions []*pb.Position
+	opt.Code, positions = optimize(opt.Code, opt.GetDebugInfo().GetPositions())
+	if opt.DebugInfo != nil {
+		opt.DebugInfo.Positions = positions
+	}
+	return opt
+}
+
+// Code returns an optimized copy of code. It doesn't modify code, but the
+// copy may share operations with it.
+func Code(code []*pb.Operation) []*pb.Operation {
+	out, _ := optimize(code, nil)
+	return out
+}
+
+// optimize returns an optimized copy of code and, if positions holds the
+// source positions of code, those of the copy.
+func optimize(code []*pb.Operation, positions []*pb.Position) ([]*pb.Operation, []*pb.Position) {
+	targets := map[int32]bool{}
+	for _, op := range code {
+		if t, ok := target(op); ok {
+			targets[t] = true
+		}
+	}
+
+	var out []*pb.Operation
+	var outPositions []*pb.Position
+	// pos maps the positions of code to those of out.
+	pos := make([]int32, len(code)+1)
+	// block is the position in out of the first operation which may be
+	// combined with the next.
+	block := 0
+	for pc, op := range code {
+		pos[pc] = int32(len(out))
+		if targets[int32(pc)] {
+			block = len(out)
+		}
+		position := &pb.Position{}
+		if pc < len(positions) {
+			position = positions[pc]
+		}
+		for {
+			var last *pb.Operation
+			if block < len(out) {
+				last = out[len(out)-1]
+			}
+			combined, ok := combine(last, op)
+			if !ok {
+				break
+			}
+			out = out[:len(out)-1]
+			position = outPositions[len(outPositions)-1]
+			outPositions = outPositions[:len(outPositions)-1]
+			if op = combined; op == nil {
+				break
+			}
+		}
+		if op == nil || isIdentity(op) {
+			continue
+		}
+		out = append(out, op)
+		outPositions = append(outPositions, position)
+	}
+	pos[len(code)] = int32(len(out))
+
+	for i, op := range out {
+		if t, ok := target(op); ok && 0 <= t && int(t) <= len(code) {
+			out[i] = retarget(op, pos[t])
+		}
+	}
+	if len(positions) == 0 {
+		return out, nil
+	}
+	return out, outPositions
+}
+
+// combine returns the operation equivalent to last followed by op, which is
+// nil if they cancel out, and true, or false if they can't be combined.
+func combine(last, op *pb.Operation) (*pb.Operation, bool) {
+	p := op.GetPermute()
+	if last == nil || p == nil {
+		return nil, false
+	}
+	if last.GetPush() != nil && p.Pop == 1 && len(p.Push) == 0 {
+		return nil, true
+	}
+	if q := last.GetPermute(); q != nil {
+		return permute(compose(q, p)), true
+	}
+	return nil, false
+}
+
+// compose returns the Permute equivalent to p followed by q.
+func compose(p, q *pb.Permute) *pb.Permute {
+	pushed := int32(len(p.Push))
+	r := &pb.Permute{Pop: p.Pop}
+	if pushed < q.Pop {
+		// q pops values below those p pushed.
+		r.Pop += q.Pop - pushed
+	} else {
+		r.Push = append(r.Push, p.Push[:pushed-q.Pop]...)
+	}
+	for _, idx := range q.Push {
+		if idx < pushed {
+			r.Push = append(r.Push, p.Push[pushed-1-idx])
+		} else {
+			r.Push = append(r.Push, p.Pop+idx-pushed)
+		}
+	}
+	return r
+}
+
+// isIdentity reports whether op is a Permute which leaves the stack as it
+// was.
+func isIdentity(op *pb.Operation) bool {
+	p := op.GetPermute()
+	if p == nil || int(p.Pop) != len(p.Push) {
+		return false
+	}
+	for i, idx := range p.Push {
+		if idx != p.Pop-1-int32(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func permute(p *pb.Permute) *pb.Operation {
+	return &pb.Operation{Op: &pb.Operation_Permute{Permute: p}}
+}
+
+// target returns the target of a control flow operation.
+func target(o *pb.Operation) (int32, bool) {
+	switch op := o.GetOp().(type) {
+	case *pb.Operation_Jump:
+		return op.Jump.Target, true
+	case *pb.Operation_Branch:
+		return op.Branch.Target, true
+	case *pb.Operation_Call:
+		return op.Call.Target, true
+	case *pb.Operation_Choice:
+		return op.Choice.Target, true
+	}
+	return 0, false
+}
+
+// retarget returns a copy of the control flow operation o with the given
+// target.
+func retarget(o *pb.Operation, t int32) *pb.Operation {
+	switch o.GetOp().(type) {
+	case *pb.Operation_Jump:
+		return &pb.Operation{Op: &pb.Operation_Jump{Jump: &pb.Jump{Target: t}}}
+	case *pb.Operation_Branch:
+		return &pb.Operation{Op: &pb.Operation_Branch{Branch: &pb.Branch{Target: t}}}
+	case *pb.Operation_Call:
+		return &pb.Operation{Op: &pb.Operation_Call{Call: &pb.Call{Target: t}}}
+	case *pb.Operation_Choice:
+		return &pb.Operation{Op: &pb.Operation_Choice{Choice: &pb.Choice{Target: t}}}
+	}
+	panic("not a control flow operation")
+}
@@ -0,0 +1,315 @@
+package optimize
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hjfreyer/stalog/asm"
+	"github.com/hjfreyer/stalog/compiler"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+	"github.com/hjfreyer/stalog/verify"
+)
+
+func TestCode(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		want string
+	}{{
+		name: "swap swap",
+		src: `
+	swap
+	swap
+`,
+	}, {
+		name: "roll roll roll",
+		src: `
+	roll 3
+	roll 3
+	roll 3
+`,
+	}, {
+		name: "identities",
+		src: `
+	permute 3 2 1 0
+	pop 0
+	dup
+	pop
+`,
+	}, {
+		name: "push pop",
+		src: `
+	push A
+	push B
+	swap
+	pop
+	pop
+	push A
+	pop
+`,
+		want: `
+	push A
+	push B
+	pop 2
+`,
+	}, {
+		name: "push permute",
+		src: `
+	push A
+	pop 2
+	push A
+	dup
+`,
+		want: `
+	push A
+	pop 2
+	push A
+	dup
+`,
+	}, {
+		name: "compose",
+		src: `
+	dup
+	roll 3
+	permute 4 3 0
+`,
+		want: `
+	permute 3 2 0
+`,
+	}, {
+		name: "targets",
+		src: `
+	call f
+	jump end
+	swap
+f:
+	swap
+	dup
+	swap
+	return
+loop:
+	pop 0
+	push A
+	pop
+	jump loop
+end:
+`,
+		want: `
+	call f
+	jump end
+	swap
+f:
+	permute 2 0 1 1
+	return
+loop:
+	jump loop
+end:
+`,
+	}, {
+		name: "removed target",
+		src: `
+	push A
+	dup
+	jump 4
+	swap
+	pop 0
+	pop
+`,
+		want: `
+	push A
+	dup
+	jump 4
+	swap
+	pop
+`,
+	}}
+
+	for _, tc := range tcs {
+		header := "package foo\nsymbol A\nsymbol B\n"
+		mod, err := asm.Assemble("", header+tc.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		want, err := asm.Assemble("", header+tc.want)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		orig := proto.Clone(mod)
+		got := Module(mod)
+		if !proto.Equal(got, want) {
+			t.Errorf("%s: got:\n%s\nwanted:\n%s", tc.name, asm.Disassemble(got), asm.Disassemble(want))
+		}
+		if !proto.Equal(mod, orig) {
+			t.Errorf("%s: original modified", tc.name)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	mod, err := asm.Assemble("", `package foo
+symbol A
+	push A
+	dup
+	roll 3
+	swap
+	swap
+	fail
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod.DebugInfo = &pb.DebugInfo{Files: []string{"foo.slm"}}
+	for line := range mod.Code {
+		mod.DebugInfo.Positions = append(mod.DebugInfo.Positions, &pb.Position{Line: int32(line + 1), Column: 1})
+	}
+	opt := Module(mod)
+	var got []int32
+	for _, p := range opt.DebugInfo.Positions {
+		got = append(got, p.Line)
+	}
+	if want := []int32{1, 2, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %v; wanted %v\n%s", got, want, asm.Disassemble(opt))
+	}
+}
+
+func TestCompiled(t *testing.T) {
+	src, err := ioutil.ReadFile("../examples/nat.slm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, main := range []string{
+		"def main = Z S S .",
+		"main :- nat(S(S(Z))).",
+		"main :- add(X, Y, S(S(Z))).",
+		"main :- add(X, S(Z), S(S(Z))), nat(X), add(X, X, S(S(Z))).",
+	} {
+		mod, err := compiler.Compile("nat.slm", string(src)+main)
+		if err != nil {
+			t.Errorf("%s: %v", main, err)
+			continue
+		}
+		opt := Module(mod)
+		if len(opt.DebugInfo.Positions) != len(opt.Code) {
+			t.Errorf("%s: got %d positions for %d operations", main, len(opt.DebugInfo.Positions), len(opt.Code))
+		}
+		if err := verify.Module(opt); err != nil {
+			t.Errorf("%s: optimized code doesn't verify: %v", main, err)
+		}
+		want, err := solutions(mod.Symbols, mod.Code)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", main, err)
+		}
+		got, err := solutions(opt.Symbols, opt.Code)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", main, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: optimized code found %v; wanted %v", main, got, want)
+		}
+	}
+}
+
+// TestRandom checks that random verified code has the same solutions, or
+// fails in the same way, when optimized.
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	symbols := []string{"A", "B"}
+	compared, shortened := 0, 0
+	for i := 0; i < 20000; i++ {
+		code := randomCode(r)
+		if verify.Code(len(symbols), code) != nil {
+			continue
+		}
+		opt := Code(code)
+		want, wantErr := solutions(symbols, code)
+		got, gotErr := solutions(symbols, opt)
+		if errors.Is(wantErr, runtime.StepLimitExceeded) || errors.Is(gotErr, runtime.StepLimitExceeded) {
+			continue
+		}
+		compared++
+		if len(opt) < len(code) {
+			shortened++
+		}
+		if !reflect.DeepEqual(got, want) || kind(gotErr) != kind(wantErr) {
+			t.Errorf("optimized code found %v, %v; wanted %v, %v\noriginal:\n%s\noptimized:\n%s",
+				got, gotErr, want, wantErr,
+				asm.Disassemble(&pb.Module{Symbols: symbols, Code: code}),
+				asm.Disassemble(&pb.Module{Symbols: symbols, Code: opt}))
+			return
+		}
+	}
+	if compared < 1000 || shortened < 100 {
+		t.Errorf("only compared %d random programs, of which %d were shortened", compared, shortened)
+	}
+}
+
+// kind returns the kind of an evaluation error, or -1.
+func kind(err error) runtime.ErrorKind {
+	var e *runtime.EvalError
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return -1
+}
+
+// solutions returns the stack and log of the first few solutions of code,
+// formatted.
+func solutions(symbols []string, code []*pb.Operation) ([]string, error) {
+	rt := runtime.Runtime{
+		Symbols:  symbols,
+		MaxSteps: 10000,
+	}
+	var got []string
+	sols := rt.Solutions(code)
+	for len(got) < 5 && sols.Next(context.Background()) {
+		var s string
+		for _, v := range rt.Stack {
+			s += rt.Format(v) + " "
+		}
+		s += "|"
+		for _, v := range rt.Log {
+			s += " " + rt.Format(v)
+		}
+		got = append(got, s)
+	}
+	return got, sols.Err()
+}
+
+// randomCode returns random code, mostly pushes and permutes.
+func randomCode(r *rand.Rand) []*pb.Operation {
+	n := 1 + r.Intn(10)
+	target := func() int32 { return int32(r.Intn(n + 1)) }
+	var code []*pb.Operation
+	for i := 0; i < n; i++ {
+		op := &pb.Operation{}
+		switch r.Intn(12) {
+		case 0, 1, 2:
+			op.Op = &pb.Operation_Push{Push: &pb.Push{SymbolIdx: int32(r.Intn(2))}}
+		case 3, 4, 5, 6:
+			pop := int32(r.Intn(4))
+			p := &pb.Permute{Pop: pop}
+			for j := r.Intn(4); 0 < pop && 0 < j; j-- {
+				p.Push = append(p.Push, int32(r.Intn(int(pop))))
+			}
+			op.Op = &pb.Operation_Permute{Permute: p}
+		case 7:
+			op.Op = &pb.Operation_Group{Group: &pb.Group{Count: int32(r.Intn(3))}}
+		case 8:
+			op.Op = &pb.Operation_Commit{Commit: &pb.Commit{}}
+		case 9:
+			op.Op = &pb.Operation_Jump{Jump: &pb.Jump{Target: target()}}
+		case 10:
+			op.Op = &pb.Operation_Choice{Choice: &pb.Choice{Target: target()}}
+		case 11:
+			op.Op = &pb.Operation_Unify{Unify: &pb.Unify{}}
+		}
+		code = append(code, op)
+	}
+	return code
+}
@@ -0,0 +1,302 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hjfreyer/stalog/ast"
+)
+
+// Error is a syntax error in a Stalog source file.
+type Error struct {
+	Pos ast.Pos
+	// Found describes the text at Pos.
+	Found string
+	// Expected describes the tokens that could have appeared at Pos.
+	Expected []string
+	// Line is the line of source containing Pos, without its newline.
+	Line string
+}
+
+// Error returns a message of the form
+//
+//	foo.slm:3:8: unexpected "z", expected symbol name
+//		symbol z
+//		       ^
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: unexpected %s", e.Pos, e.Found)
+	if len(e.Expected) != 0 {
+		fmt.Fprintf(&b, ", expected %s", orList(e.Expected))
+	}
+	fmt.Fprintf(&b, "\n\t%s\n\t", e.Line)
+	// Copy tabs so the caret lines up however tabs are rendered.
+	for _, c := range []rune(e.Line)[:e.Pos.Column-1] {
+		if c == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	b.WriteString("^")
+	return b.String()
+}
+
+// ErrorList is a list of syntax errors, in the order they appear in the
+// source.
+type ErrorList []*Error
+
+// Error returns the messages of the errors, one after another.
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func orList(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// probes are tokens which are tried at the position of a syntax error to
+// find out what was expected there. Keywords are followed by what must come
+// after them, so they aren't mistaken for def names.
+var probes = []struct {
+	desc, text string
+	keyword    bool
+}{
+	{"'package'", "package q", true},
+	{"'import'", "import \"q\"", true},
+	{"'symbol'", "symbol Q", true},
+	{"'def'", "def q =", true},
+	{"symbol name", "Q", false},
+	{"def name", "q", false},
+	{"'='", "=", false},
+	{"'('", "(", false},
+	{"')'", ")", false},
+	{"','", ",", false},
+	{"':-'", ":-", false},
+	{"'.'", ".", false},
+}
+
+// farthest parses src and returns the offset just past the last token
+// successfully matched if it fails to parse, or -1 if it parses.
+func farthest(src []rune) int {
+	p := StalogAST{Buffer: string(src)}
+	p.Init()
+	return farthestError(p.Parse())
+}
+
+// farthestError returns the offset just past the last token matched by a
+// failed parse, or -1 if err is nil.
+func farthestError(err error) int {
+	if err == nil {
+		return -1
+	}
+	return int(err.(*parseError).max.end)
+}
+
+// syntaxError builds an Error for text, which failed to parse at offset. The
+// error is reported at the farthest point the parser reached, and a token is
+// expected there if inserting it lets the parser get past it. text is the
+// source with any definitions skipped by earlier errors blanked out.
+func (b *builder) syntaxError(text []rune, offset int) *Error {
+	var want []string
+	expected := map[string]bool{}
+	for _, probe := range probes {
+		// Surround the probe with spaces so it can't run into the words
+		// around it.
+		probeText := []rune(" " + probe.text + " ")
+		var probed []rune
+		probed = append(probed, text[:offset]...)
+		probed = append(probed, probeText...)
+		probed = append(probed, text[offset:]...)
+		if end := farthest(probed); end < 0 || offset+len(probeText)-1 < end {
+			expected[probe.desc] = true
+		}
+	}
+	// Where def names are expected, keywords are too, as words in a def's
+	// body. Only mention keywords where a definition can start.
+	keywords := !expected["def name"] || expected["'def'"]
+	for _, probe := range probes {
+		if expected[probe.desc] && (keywords || !probe.keyword) {
+			want = append(want, probe.desc)
+		}
+	}
+	if farthest(text[:offset]) < 0 {
+		want = append(want, "end of file")
+	}
+	return b.errorAt(offset, found(text, offset), want...)
+}
+
+// errorAt builds an Error at offset, where found was found instead of the
+// expected tokens.
+func (b *builder) errorAt(offset int, found string, expected ...string) *Error {
+	e := &Error{
+		Pos:      b.pos(uint32(offset)),
+		Found:    found,
+		Expected: expected,
+	}
+	start := b.lines[e.Pos.Line-1]
+	end := start
+	for end < len(b.src) && b.src[end] != '\n' {
+		end++
+	}
+	e.Line = string(b.src[start:end])
+	return e
+}
+
+// skip returns a copy of text, which failed to parse at offset, with the
+// definition containing the error blanked out so that parsing can resume
+// after it. Newlines are kept so positions in the copy match the original.
+// It returns nil if there is nowhere to resume.
+func skip(text []rune, offset int) []rune {
+	start, end := brokenDefinition(text, offset)
+	if start < 0 {
+		return nil
+	}
+	skipped := append([]rune(nil), text...)
+	blanked := false
+	for i := start; i < end; i++ {
+		switch skipped[i] {
+		case ' ', '\n', '\r', '\t':
+		default:
+			skipped[i] = ' '
+			blanked = true
+		}
+	}
+	if !blanked {
+		return nil
+	}
+	return skipped
+}
+
+// brokenDefinition returns the span of the definition containing offset,
+// where text failed to parse, found in one scan of its tokens. If offset is
+// between definitions, the definition starts there and is taken to be a
+// clause. It ends just after the period ending a def or clause, after the
+// name of a symbol or the path of an import, or at the next name at the
+// start of a line, which starts another definition, whichever comes first.
+// start is -1 if offset is in the package clause.
+func brokenDefinition(text []rune, offset int) (start, end int) {
+	const (
+		packageKeyword = iota
+		packageName
+		between
+		importPath
+		symbolName
+		body
+	)
+	state, start := packageKeyword, -1
+	for i := 0; ; {
+		from, to := token(text, i)
+		if offset <= from {
+			break
+		}
+		tok := string(text[from:to])
+		switch state {
+		case packageKeyword:
+			state = packageName
+		case importPath:
+			// A path the line ends before doesn't finish the import.
+			if 1 < len(tok) && strings.HasSuffix(tok, `"`) {
+				state = between
+			}
+		case packageName, symbolName:
+			state = between
+		case between:
+			start = from
+			switch tok {
+			case "import":
+				state = importPath
+			case "symbol":
+				state = symbolName
+			default:
+				// Defs and clauses run to a period.
+				state = body
+			}
+		case body:
+			if tok == "." {
+				state = between
+			}
+		}
+		i = to
+	}
+	switch state {
+	case packageKeyword, packageName:
+		return -1, -1
+	case between:
+		start, state = offset, body
+	}
+	for i := offset; ; {
+		from, to := token(text, i)
+		switch {
+		case from == len(text):
+			return start, from
+		case start < from && (from == 0 || text[from-1] == '\n') && isWordChar(text[from]):
+			return start, from
+		case state != body || text[from] == '.':
+			return start, to
+		}
+		i = to
+	}
+}
+
+// token returns the span of the first token at or after i in text, skipping
+// spaces and comments, or an empty span at the end of text if there is none.
+// Words, paths and other characters are tokens.
+func token(text []rune, i int) (start, end int) {
+	for i < len(text) {
+		if c := text[i]; c == '#' {
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		} else if c == ' ' || c == '\n' || c == '\r' || c == '\t' {
+			i++
+		} else {
+			break
+		}
+	}
+	if i == len(text) {
+		return i, i
+	}
+	end = i + 1
+	switch {
+	case isWordChar(text[i]):
+		for end < len(text) && isWordChar(text[end]) {
+			end++
+		}
+	case text[i] == '"':
+		for end < len(text) && text[end] != '"' && text[end] != '\n' {
+			end++
+		}
+		if end < len(text) && text[end] == '"' {
+			end++
+		}
+	}
+	return i, end
+}
+
+// found describes the token at offset in src.
+func found(src []rune, offset int) string {
+	if len(src) <= offset {
+		return "end of file"
+	}
+	end := offset
+	for end < len(src) && isWordChar(src[end]) {
+		end++
+	}
+	if end == offset {
+		end++
+	}
+	return strconv.Quote(string(src[offset:end]))
+}
+
+func isWordChar(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
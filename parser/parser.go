@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/hjfreyer/stalog/ast"
+)
+
+// ParseFile parses src as the Stalog source file named filename. If src
+// doesn't parse, the error is an ErrorList. After a syntax error, parsing
+// resumes after the broken definition: after the period ending a def or
+// clause, after the name of a symbol or the path of an import, or at the
+// next line starting with a name, whichever comes first. So one pass reports
+// as many errors as possible; the returned module then holds the definitions
+// which did parse, or is nil if the package clause didn't.
+func ParseFile(filename, src string) (*ast.Module, error) {
+	text := []rune(src)
+	b := builder{
+		filename: filename,
+		src:      []rune(src),
+		lines:    lineStarts(text),
+	}
+	for {
+		p := StalogAST{Buffer: string(text)}
+		p.Init()
+		offset := farthestError(p.Parse())
+		if offset < 0 {
+			b.buffer = p.buffer
+			m := b.module(p.AST())
+			if len(b.errs) != 0 {
+				// Errors found building the module may come before
+				// syntax errors found earlier.
+				sort.SliceStable(b.errs, func(i, j int) bool { return b.errs[i].Pos.Offset < b.errs[j].Pos.Offset })
+				return m, b.errs
+			}
+			return m, nil
+		}
+		b.errs = append(b.errs, b.syntaxError(text, offset))
+		if text = skip(text, offset); text == nil {
+			return nil, b.errs
+		}
+	}
+}
+
+// builder converts the generated parse tree into an ast.Module.
+type builder struct {
+	filename string
+	// src is the source being parsed, and buffer the text the parse tree
+	// was built from, which has any definitions skipped by earlier errors
+	// blanked out.
+	src    []rune
+	buffer []rune
+	// lines holds the offset of the start of each line.
+	lines []int
+	errs  ErrorList
+}
+
+func lineStarts(buffer []rune) []int {
+	lines := []int{0}
+	for i, c := range buffer {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func (b *builder) pos(offset uint32) ast.Pos {
+	// Find the last line starting at or before offset.
+	lo, hi := 0, len(b.lines)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if b.lines[mid] <= int(offset) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return ast.Pos{
+		Filename: b.filename,
+		Offset:   int(offset),
+		Line:     lo + 1,
+		Column:   int(offset) - b.lines[lo] + 1,
+	}
+}
+
+// children returns the children of n produced by rule.
+func children(n *node32, rule pegRule) []*node32 {
+	var nodes []*node32
+	for c := n.up; c != nil; c = c.next {
+		if c.pegRule == rule {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// child returns the first child of n produced by rule, or nil.
+func child(n *node32, rule pegRule) *node32 {
+	for c := n.up; c != nil; c = c.next {
+		if c.pegRule == rule {
+			return c
+		}
+	}
+	return nil
+}
+
+// name returns the text of a SymbolName, DefName, Identifier or Path node
+// without its punctuation or trailing spacing.
+func (b *builder) name(n *node32) string {
+	for n.pegRule != rulePegText {
+		n = n.up
+	}
+	return string(b.buffer[n.begin:n.end])
+}
+
+func (b *builder) module(n *node32) *ast.Module {
+	pkg := child(n, ruleIdentifier)
+	m := &ast.Module{
+		Pos:        b.pos(n.begin),
+		Package:    b.name(pkg),
+		PackagePos: b.pos(pkg.begin),
+	}
+	for _, imp := range children(n, ruleImport) {
+		// The grammar allows an empty path, which has no text node.
+		if path := child(imp, rulePath); child(path, rulePegText) == nil {
+			// Report the closing quote.
+			offset := int(path.begin) + 1
+			b.errs = append(b.errs, b.errorAt(offset, found(b.buffer, offset), "import path"))
+			continue
+		}
+		m.Imports = append(m.Imports, &ast.Import{
+			Pos:  b.pos(imp.begin),
+			Path: b.name(child(imp, rulePath)),
+		})
+	}
+	for _, d := range children(n, ruleDefinition) {
+		m.Defs = append(m.Defs, b.definition(d.up))
+	}
+	b.comments(m, n)
+	return m
+}
+
+func (b *builder) comments(m *ast.Module, n *node32) {
+	for c := n.up; c != nil; c = c.next {
+		if c.pegRule == ruleComment {
+			end := c.end
+			if b.buffer[end-1] == '\n' {
+				end--
+			}
+			m.Comments = append(m.Comments, &ast.Comment{
+				Pos:  b.pos(c.begin),
+				Text: string(b.buffer[c.begin:end]),
+			})
+			continue
+		}
+		b.comments(m, c)
+	}
+}
+
+func (b *builder) definition(n *node32) ast.Def {
+	switch n.pegRule {
+	case ruleSymbolDef:
+		return &ast.SymbolDef{
+			Pos:  b.pos(n.begin),
+			Name: b.name(child(n, ruleSymbolName)),
+		}
+	case ruleCodeDef:
+		d := &ast.CodeDef{
+			Pos:  b.pos(n.begin),
+			Name: b.name(child(n, ruleDefName)),
+		}
+		for _, w := range children(n, ruleWord) {
+			d.Words = append(d.Words, &ast.Word{
+				Pos:     b.pos(w.begin),
+				Package: b.qualifier(w),
+				Name:    b.name(child(w, ruleIdentifier)),
+			})
+		}
+		return d
+	case ruleClause:
+		c := &ast.Clause{
+			Pos:  b.pos(n.begin),
+			Head: b.goal(child(n, ruleHead)),
+		}
+		if body := child(n, ruleBody); body != nil {
+			for _, g := range children(body, ruleGoal) {
+				c.Body = append(c.Body, b.goal(g))
+			}
+		}
+		return c
+	}
+	panic("unknown definition " + rul3s[n.pegRule])
+}
+
+func (b *builder) goal(n *node32) *ast.Goal {
+	return &ast.Goal{
+		Pos:     b.pos(n.begin),
+		Package: b.qualifier(n),
+		Name:    b.name(child(n, ruleDefName)),
+		Args:    b.arguments(n),
+	}
+}
+
+// qualifier returns the package qualifying the name in n, if any.
+func (b *builder) qualifier(n *node32) string {
+	if q := child(n, ruleQualifier); q != nil {
+		// Drop the ':'.
+		return string(b.buffer[q.begin : q.end-1])
+	}
+	return ""
+}
+
+func (b *builder) arguments(n *node32) []*ast.Term {
+	args := child(n, ruleArguments)
+	if args == nil {
+		return nil
+	}
+	var terms []*ast.Term
+	for _, t := range children(args, ruleTerm) {
+		terms = append(terms, &ast.Term{
+			Pos:     b.pos(t.begin),
+			Package: b.qualifier(t),
+			Name:    b.name(child(t, ruleSymbolName)),
+			Args:    b.arguments(t),
+		})
+	}
+	return terms
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hjfreyer/stalog/ast"
+)
+
+func TestParse(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		ok   bool
+	}{
+		{"package only", "package foo", true},
+		{"comments", "# hi\npackage foo # there\n", true},
+		{"comment at end of file", "package foo\nsymbol Z # no newline", true},
+		{"symbols", "package foo symbol Z symbol S", true},
+		{"missing package", "symbol Z", false},
+		{"lowercase symbol", "package foo symbol z", false},
+		{"def", "package foo symbol Z def main = Z Z one .", true},
+		{"empty def", "package foo def main = .", true},
+		{"def without terminator", "package foo def main = Z", false},
+		{"fact", "package foo nat(Z).", true},
+		{"atom fact", "package foo main.", true},
+		{"rule", "package foo nat(S(X)) :- nat(X).", true},
+		{"rule with spacing", "package foo\nadd( S(X) , Y,S( Z ) ) :-\n\tadd(X, Y, Z) ,\n\tnat(X) .", true},
+		{"body without goals", "package foo nat(X) :- .", false},
+		{"empty arguments", "package foo nat().", false},
+		{"lowercase term", "package foo nat(z).", false},
+		{"clause without terminator", "package foo nat(Z)", false},
+		{"clause named like keyword", "package foo define(X). symbolic.", true},
+		{"imports", "package foo import \"nat\" import \"a/b\" symbol Z", true},
+		{"import after definition", "package foo symbol Z import \"nat\"", false},
+		{"unterminated import", "package foo import \"nat\n\"", false},
+		{"empty import", "package foo import \"\"", false},
+		{"qualified names", "package foo def main = nat:Z nat:one . main :- nat:add(nat:S(X), Y).", true},
+		{"qualified head", "package foo nat:add(X).", false},
+		{"spaced qualifier", "package foo def main = nat: Z .", false},
+	}
+
+	for _, tc := range tcs {
+		_, err := ParseFile("", tc.src)
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	src := `# Naturals.
+package nat
+
+symbol Z
+nat(S(X)) :- nat(X), # Recurse.
+  foo.
+def main = Z foo .
+`
+	got, err := ParseFile("nat.slm", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := func(offset, line, column int) ast.Pos {
+		return ast.Pos{Filename: "nat.slm", Offset: offset, Line: line, Column: column}
+	}
+	want := &ast.Module{
+		Pos:        pos(0, 1, 1),
+		Package:    "nat",
+		PackagePos: pos(20, 2, 9),
+		Defs: []ast.Def{
+			&ast.SymbolDef{Pos: pos(25, 4, 1), Name: "Z"},
+			&ast.Clause{
+				Pos: pos(34, 5, 1),
+				Head: &ast.Goal{
+					Pos:  pos(34, 5, 1),
+					Name: "nat",
+					Args: []*ast.Term{{
+						Pos:  pos(38, 5, 5),
+						Name: "S",
+						Args: []*ast.Term{{Pos: pos(40, 5, 7), Name: "X"}},
+					}},
+				},
+				Body: []*ast.Goal{{
+					Pos:  pos(47, 5, 14),
+					Name: "nat",
+					Args: []*ast.Term{{Pos: pos(51, 5, 18), Name: "X"}},
+				}, {
+					Pos:  pos(68, 6, 3),
+					Name: "foo",
+				}},
+			},
+			&ast.CodeDef{
+				Pos:  pos(73, 7, 1),
+				Name: "main",
+				Words: []*ast.Word{
+					{Pos: pos(84, 7, 12), Name: "Z"},
+					{Pos: pos(86, 7, 14), Name: "foo"},
+				},
+			},
+		},
+		Comments: []*ast.Comment{
+			{Pos: pos(0, 1, 1), Text: "# Naturals."},
+			{Pos: pos(55, 5, 22), Text: "# Recurse."},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong module. Got:\n%s; wanted:\n%s", dump(got), dump(want))
+	}
+}
+
+func TestParseImports(t *testing.T) {
+	src := `package foo
+import "lib/nat"
+def main = nat:Z two .
+two :- nat:add(nat:S(X), X).
+`
+	got, err := ParseFile("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := func(offset, line, column int) ast.Pos {
+		return ast.Pos{Offset: offset, Line: line, Column: column}
+	}
+	want := &ast.Module{
+		Pos:        pos(0, 1, 1),
+		Package:    "foo",
+		PackagePos: pos(8, 1, 9),
+		Imports:    []*ast.Import{{Pos: pos(12, 2, 1), Path: "lib/nat"}},
+		Defs: []ast.Def{
+			&ast.CodeDef{
+				Pos:  pos(29, 3, 1),
+				Name: "main",
+				Words: []*ast.Word{
+					{Pos: pos(40, 3, 12), Package: "nat", Name: "Z"},
+					{Pos: pos(46, 3, 18), Name: "two"},
+				},
+			},
+			&ast.Clause{
+				Pos:  pos(52, 4, 1),
+				Head: &ast.Goal{Pos: pos(52, 4, 1), Name: "two"},
+				Body: []*ast.Goal{{
+					Pos:     pos(59, 4, 8),
+					Package: "nat",
+					Name:    "add",
+					Args: []*ast.Term{{
+						Pos:     pos(67, 4, 16),
+						Package: "nat",
+						Name:    "S",
+						Args:    []*ast.Term{{Pos: pos(73, 4, 22), Name: "X"}},
+					}, {
+						Pos:  pos(77, 4, 26),
+						Name: "X",
+					}},
+				}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong module. Got:\n%s; wanted:\n%s", dump(got), dump(want))
+	}
+}
+
+func dump(v interface{}) string {
+	b, _ := json.MarshalIndent(v, "", "  ")
+	return string(b)
+}
+
+func TestSyntaxError(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"missing package", "symbol Z", `f.slm:1:1: unexpected "symbol", expected 'package'
+	symbol Z
+	^`},
+		{"def without terminator", "package foo def main = Z", `f.slm:1:25: unexpected end of file, expected symbol name, def name or '.'
+	package foo def main = Z
+	                        ^`},
+		{"clause without terminator", "package foo\n\tnat(Z)", `f.slm:2:8: unexpected end of file, expected ':-' or '.'
+		nat(Z)
+		      ^`},
+		{"empty arguments", "package foo nat().", `f.slm:1:17: unexpected ")", expected symbol name
+	package foo nat().
+	                ^`},
+		{"body without goals", "package foo nat(X) :- .", `f.slm:1:23: unexpected ".", expected def name
+	package foo nat(X) :- .
+	                      ^`},
+		{"empty import", "package foo\nimport \"\"\n", `f.slm:2:9: unexpected "\"", expected import path
+	import ""
+	        ^`},
+	}
+
+	for _, tc := range tcs {
+		_, err := ParseFile("f.slm", tc.src)
+		if errs, ok := err.(ErrorList); !ok || len(errs) != 1 {
+			t.Errorf("%s: got error %v; wanted an ErrorList of one error", tc.name, err)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("%s: wrong error. Got:\n%s\nwanted:\n%s", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		// want lists the positions of the expected errors.
+		want []string
+		// defs is the number of definitions in the recovered module, or -1
+		// if there should be none.
+		defs int
+	}{
+		{"bad symbols", "package foo\nsymbol z\nsymbol Z\nsymbol y\nsymbol x\n", []string{"2:8", "4:8", "5:8"}, 1},
+		{"bad defs", "package foo def = . def main = Z . def 3 = .", []string{"1:17", "1:40"}, 1},
+		{"bad clause then symbol", "package foo nat(Z\nsymbol Z nat(Z).", []string{"2:1"}, 2},
+		{"bad clauses", "package foo\nnat(Z.\nnat(S(X)) :- nat(X).\nadd(Z Y, Y).\nadd(Z, Y, Y).\n", []string{"2:6", "4:7"}, 2},
+		{"bad clauses in a row", "package foo\nbad(.\nbad(Z Z).\nbad :- .\nbad(Z) :-\n  bad(Z)\nok.\n", []string{"2:5", "3:7", "4:8", "7:1"}, 1},
+		{"same line", "package foo symbol z symbol y symbol X", []string{"1:20", "1:29"}, 1},
+		{"keyword in comment", "package foo\nsymbol z # symbol Y\n symbol y\n", []string{"2:8", "3:9"}, 0},
+		{"keyword in def body", "package foo def main = symbol Z z ( . symbol Y", []string{"1:35"}, 1},
+		{"clause after bad clause", "package foo nat(Z) :- nat(z). nat(S(X)) :- nat(X).", []string{"1:27"}, 1},
+		{"nowhere to resume", "package foo nat(Z) :- nat(z) nat(S(X)) :- nat(X)", []string{"1:27"}, 0},
+		{"bad package", "package 3 symbol Z\nsymbol z", []string{"1:9"}, -1},
+		{"empty import before syntax error", "package foo\nimport \"\"\nsymbol z\nsymbol Z\n", []string{"2:9", "3:8"}, 1},
+	}
+
+	for _, tc := range tcs {
+		m, err := ParseFile("", tc.src)
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("%s: got error %v; wanted an ErrorList", tc.name, err)
+			continue
+		}
+		var got []string
+		for _, e := range errs {
+			got = append(got, e.Pos.String())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got errors at %v; wanted %v. Errors:\n%v", tc.name, got, tc.want, err)
+		}
+		if tc.defs < 0 {
+			if m != nil {
+				t.Errorf("%s: got module %s; wanted none", tc.name, dump(m))
+			}
+		} else if m == nil || len(m.Defs) != tc.defs {
+			t.Errorf("%s: got module %s; wanted %d definitions", tc.name, dump(m), tc.defs)
+		}
+	}
+}
@@ -17,11 +17,28 @@ type pegRule uint8
 const (
 	ruleUnknown pegRule = iota
 	ruleModule
+	ruleImport
+	rulePath
 	ruleDefinition
 	ruleSymbolDef
+	ruleCodeDef
+	ruleClause
+	ruleHead
+	ruleBody
+	ruleGoal
+	ruleArguments
+	ruleTerm
+	ruleWord
+	ruleQualifier
 	ruleIdentifier
 	ruleSymbolName
 	ruleDefName
+	ruleEquals
+	rulePeriod
+	ruleComma
+	ruleOpen
+	ruleClose
+	ruleIf
 	ruleSpace
 	ruleSpacing
 	ruleWhiteSpace
@@ -34,11 +51,28 @@ const (
 var rul3s = [...]string{
 	"Unknown",
 	"Module",
+	"Import",
+	"Path",
 	"Definition",
 	"SymbolDef",
+	"CodeDef",
+	"Clause",
+	"Head",
+	"Body",
+	"Goal",
+	"Arguments",
+	"Term",
+	"Word",
+	"Qualifier",
 	"Identifier",
 	"SymbolName",
 	"DefName",
+	"Equals",
+	"Period",
+	"Comma",
+	"Open",
+	"Close",
+	"If",
 	"Space",
 	"Spacing",
 	"WhiteSpace",
@@ -160,7 +194,7 @@ func (t *tokens32) Tokens() []token32 {
 type StalogAST struct {
 	Buffer string
 	buffer []rune
-	rules  [14]func() bool
+	rules  [31]func() bool
 	parse  func(rule ...int) error
 	reset  func()
 	Pretty bool
@@ -311,7 +345,7 @@ func (p *StalogAST) Init() {
 
 	_rules = [...]func() bool{
 		nil,
-		/* 0 Module <- <(Spacing ('p' 'a' 'c' 'k' 'a' 'g' 'e') Spacing Identifier Definition* EndOfFile)> */
+		/* 0 Module <- <(Spacing ('p' 'a' 'c' 'k' 'a' 'g' 'e') Spacing Identifier Import* Definition* EndOfFile)> */
 		func() bool {
 			position0, tokenIndex0 := position, tokenIndex
 			{
@@ -356,13 +390,23 @@ func (p *StalogAST) Init() {
 			l2:
 				{
 					position3, tokenIndex3 := position, tokenIndex
-					if !_rules[ruleDefinition]() {
+					if !_rules[ruleImport]() {
 						goto l3
 					}
 					goto l2
 				l3:
 					position, tokenIndex = position3, tokenIndex3
 				}
+			l4:
+				{
+					position5, tokenIndex5 := position, tokenIndex
+					if !_rules[ruleDefinition]() {
+						goto l5
+					}
+					goto l4
+				l5:
+					position, tokenIndex = position5, tokenIndex5
+				}
 				if !_rules[ruleEndOfFile]() {
 					goto l0
 				}
@@ -373,371 +417,932 @@ func (p *StalogAST) Init() {
 			position, tokenIndex = position0, tokenIndex0
 			return false
 		},
-		/* 1 Definition <- <SymbolDef> */
-		func() bool {
-			position4, tokenIndex4 := position, tokenIndex
-			{
-				position5 := position
-				if !_rules[ruleSymbolDef]() {
-					goto l4
-				}
-				add(ruleDefinition, position5)
-			}
-			return true
-		l4:
-			position, tokenIndex = position4, tokenIndex4
-			return false
-		},
-		/* 2 SymbolDef <- <('s' 'y' 'm' 'b' 'o' 'l' Spacing SymbolName)> */
+		/* 1 Import <- <('i' 'm' 'p' 'o' 'r' 't' Spacing Path)> */
 		func() bool {
 			position6, tokenIndex6 := position, tokenIndex
 			{
 				position7 := position
-				if buffer[position] != rune('s') {
+				if buffer[position] != rune('i') {
 					goto l6
 				}
 				position++
-				if buffer[position] != rune('y') {
+				if buffer[position] != rune('m') {
 					goto l6
 				}
 				position++
-				if buffer[position] != rune('m') {
+				if buffer[position] != rune('p') {
 					goto l6
 				}
 				position++
-				if buffer[position] != rune('b') {
+				if buffer[position] != rune('o') {
 					goto l6
 				}
 				position++
-				if buffer[position] != rune('o') {
+				if buffer[position] != rune('r') {
 					goto l6
 				}
 				position++
-				if buffer[position] != rune('l') {
+				if buffer[position] != rune('t') {
 					goto l6
 				}
 				position++
 				if !_rules[ruleSpacing]() {
 					goto l6
 				}
-				if !_rules[ruleSymbolName]() {
+				if !_rules[rulePath]() {
 					goto l6
 				}
-				add(ruleSymbolDef, position7)
+				add(ruleImport, position7)
 			}
 			return true
 		l6:
 			position, tokenIndex = position6, tokenIndex6
 			return false
 		},
-		/* 3 Identifier <- <(SymbolName / DefName)> */
+		/* 2 Path <- <('"' <(!'"' !EndOfLine .)*> '"' Spacing)> */
 		func() bool {
 			position8, tokenIndex8 := position, tokenIndex
 			{
 				position9 := position
+				if buffer[position] != rune('"') {
+					goto l8
+				}
+				position++
 				{
-					position10, tokenIndex10 := position, tokenIndex
-					if !_rules[ruleSymbolName]() {
-						goto l11
-					}
-					goto l10
+					position10 := position
 				l11:
-					position, tokenIndex = position10, tokenIndex10
-					if !_rules[ruleDefName]() {
-						goto l8
+					{
+						position12, tokenIndex12 := position, tokenIndex
+						{
+							position13, tokenIndex13 := position, tokenIndex
+							if buffer[position] != rune('"') {
+								goto l13
+							}
+							position++
+							goto l12
+						l13:
+							position, tokenIndex = position13, tokenIndex13
+						}
+						{
+							position14, tokenIndex14 := position, tokenIndex
+							if !_rules[ruleEndOfLine]() {
+								goto l14
+							}
+							goto l12
+						l14:
+							position, tokenIndex = position14, tokenIndex14
+						}
+						if !matchDot() {
+							goto l12
+						}
+						goto l11
+					l12:
+						position, tokenIndex = position12, tokenIndex12
 					}
+					add(rulePegText, position10)
+				}
+				if buffer[position] != rune('"') {
+					goto l8
 				}
-			l10:
-				add(ruleIdentifier, position9)
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l8
+				}
+				add(rulePath, position9)
 			}
 			return true
 		l8:
 			position, tokenIndex = position8, tokenIndex8
 			return false
 		},
-		/* 4 SymbolName <- <(<([A-Z] ([a-z] / [A-Z] / ([0-9] / [0-9]))*)> Spacing)> */
+		/* 3 Definition <- <(SymbolDef / CodeDef / Clause)> */
+		func() bool {
+			position15, tokenIndex15 := position, tokenIndex
+			{
+				position16 := position
+				{
+					position17, tokenIndex17 := position, tokenIndex
+					if !_rules[ruleSymbolDef]() {
+						goto l18
+					}
+					goto l17
+				l18:
+					position, tokenIndex = position17, tokenIndex17
+					if !_rules[ruleCodeDef]() {
+						goto l19
+					}
+					goto l17
+				l19:
+					position, tokenIndex = position17, tokenIndex17
+					if !_rules[ruleClause]() {
+						goto l15
+					}
+				}
+			l17:
+				add(ruleDefinition, position16)
+			}
+			return true
+		l15:
+			position, tokenIndex = position15, tokenIndex15
+			return false
+		},
+		/* 4 SymbolDef <- <('s' 'y' 'm' 'b' 'o' 'l' Spacing SymbolName)> */
+		func() bool {
+			position20, tokenIndex20 := position, tokenIndex
+			{
+				position21 := position
+				if buffer[position] != rune('s') {
+					goto l20
+				}
+				position++
+				if buffer[position] != rune('y') {
+					goto l20
+				}
+				position++
+				if buffer[position] != rune('m') {
+					goto l20
+				}
+				position++
+				if buffer[position] != rune('b') {
+					goto l20
+				}
+				position++
+				if buffer[position] != rune('o') {
+					goto l20
+				}
+				position++
+				if buffer[position] != rune('l') {
+					goto l20
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l20
+				}
+				if !_rules[ruleSymbolName]() {
+					goto l20
+				}
+				add(ruleSymbolDef, position21)
+			}
+			return true
+		l20:
+			position, tokenIndex = position20, tokenIndex20
+			return false
+		},
+		/* 5 CodeDef <- <('d' 'e' 'f' Spacing DefName Equals Word* Period)> */
+		func() bool {
+			position22, tokenIndex22 := position, tokenIndex
+			{
+				position23 := position
+				if buffer[position] != rune('d') {
+					goto l22
+				}
+				position++
+				if buffer[position] != rune('e') {
+					goto l22
+				}
+				position++
+				if buffer[position] != rune('f') {
+					goto l22
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l22
+				}
+				if !_rules[ruleDefName]() {
+					goto l22
+				}
+				if !_rules[ruleEquals]() {
+					goto l22
+				}
+			l24:
+				{
+					position25, tokenIndex25 := position, tokenIndex
+					if !_rules[ruleWord]() {
+						goto l25
+					}
+					goto l24
+				l25:
+					position, tokenIndex = position25, tokenIndex25
+				}
+				if !_rules[rulePeriod]() {
+					goto l22
+				}
+				add(ruleCodeDef, position23)
+			}
+			return true
+		l22:
+			position, tokenIndex = position22, tokenIndex22
+			return false
+		},
+		/* 6 Clause <- <(Head (If Body)? Period)> */
+		func() bool {
+			position26, tokenIndex26 := position, tokenIndex
+			{
+				position27 := position
+				if !_rules[ruleHead]() {
+					goto l26
+				}
+				{
+					position28, tokenIndex28 := position, tokenIndex
+					if !_rules[ruleIf]() {
+						goto l28
+					}
+					if !_rules[ruleBody]() {
+						goto l28
+					}
+					goto l29
+				l28:
+					position, tokenIndex = position28, tokenIndex28
+				}
+			l29:
+				if !_rules[rulePeriod]() {
+					goto l26
+				}
+				add(ruleClause, position27)
+			}
+			return true
+		l26:
+			position, tokenIndex = position26, tokenIndex26
+			return false
+		},
+		/* 7 Head <- <(DefName Arguments?)> */
+		func() bool {
+			position30, tokenIndex30 := position, tokenIndex
+			{
+				position31 := position
+				if !_rules[ruleDefName]() {
+					goto l30
+				}
+				{
+					position32, tokenIndex32 := position, tokenIndex
+					if !_rules[ruleArguments]() {
+						goto l32
+					}
+					goto l33
+				l32:
+					position, tokenIndex = position32, tokenIndex32
+				}
+			l33:
+				add(ruleHead, position31)
+			}
+			return true
+		l30:
+			position, tokenIndex = position30, tokenIndex30
+			return false
+		},
+		/* 8 Body <- <(Goal (Comma Goal)*)> */
+		func() bool {
+			position34, tokenIndex34 := position, tokenIndex
+			{
+				position35 := position
+				if !_rules[ruleGoal]() {
+					goto l34
+				}
+			l36:
+				{
+					position37, tokenIndex37 := position, tokenIndex
+					if !_rules[ruleComma]() {
+						goto l37
+					}
+					if !_rules[ruleGoal]() {
+						goto l37
+					}
+					goto l36
+				l37:
+					position, tokenIndex = position37, tokenIndex37
+				}
+				add(ruleBody, position35)
+			}
+			return true
+		l34:
+			position, tokenIndex = position34, tokenIndex34
+			return false
+		},
+		/* 9 Goal <- <(Qualifier? DefName Arguments?)> */
+		func() bool {
+			position38, tokenIndex38 := position, tokenIndex
+			{
+				position39 := position
+				{
+					position40, tokenIndex40 := position, tokenIndex
+					if !_rules[ruleQualifier]() {
+						goto l40
+					}
+					goto l41
+				l40:
+					position, tokenIndex = position40, tokenIndex40
+				}
+			l41:
+				if !_rules[ruleDefName]() {
+					goto l38
+				}
+				{
+					position42, tokenIndex42 := position, tokenIndex
+					if !_rules[ruleArguments]() {
+						goto l42
+					}
+					goto l43
+				l42:
+					position, tokenIndex = position42, tokenIndex42
+				}
+			l43:
+				add(ruleGoal, position39)
+			}
+			return true
+		l38:
+			position, tokenIndex = position38, tokenIndex38
+			return false
+		},
+		/* 10 Arguments <- <(Open Term (Comma Term)* Close)> */
 		func() bool {
-			position12, tokenIndex12 := position, tokenIndex
+			position44, tokenIndex44 := position, tokenIndex
 			{
-				position13 := position
+				position45 := position
+				if !_rules[ruleOpen]() {
+					goto l44
+				}
+				if !_rules[ruleTerm]() {
+					goto l44
+				}
+			l46:
+				{
+					position47, tokenIndex47 := position, tokenIndex
+					if !_rules[ruleComma]() {
+						goto l47
+					}
+					if !_rules[ruleTerm]() {
+						goto l47
+					}
+					goto l46
+				l47:
+					position, tokenIndex = position47, tokenIndex47
+				}
+				if !_rules[ruleClose]() {
+					goto l44
+				}
+				add(ruleArguments, position45)
+			}
+			return true
+		l44:
+			position, tokenIndex = position44, tokenIndex44
+			return false
+		},
+		/* 11 Term <- <(Qualifier? SymbolName Arguments?)> */
+		func() bool {
+			position48, tokenIndex48 := position, tokenIndex
+			{
+				position49 := position
 				{
-					position14 := position
+					position50, tokenIndex50 := position, tokenIndex
+					if !_rules[ruleQualifier]() {
+						goto l50
+					}
+					goto l51
+				l50:
+					position, tokenIndex = position50, tokenIndex50
+				}
+			l51:
+				if !_rules[ruleSymbolName]() {
+					goto l48
+				}
+				{
+					position52, tokenIndex52 := position, tokenIndex
+					if !_rules[ruleArguments]() {
+						goto l52
+					}
+					goto l53
+				l52:
+					position, tokenIndex = position52, tokenIndex52
+				}
+			l53:
+				add(ruleTerm, position49)
+			}
+			return true
+		l48:
+			position, tokenIndex = position48, tokenIndex48
+			return false
+		},
+		/* 12 Word <- <(Qualifier? Identifier)> */
+		func() bool {
+			position54, tokenIndex54 := position, tokenIndex
+			{
+				position55 := position
+				{
+					position56, tokenIndex56 := position, tokenIndex
+					if !_rules[ruleQualifier]() {
+						goto l56
+					}
+					goto l57
+				l56:
+					position, tokenIndex = position56, tokenIndex56
+				}
+			l57:
+				if !_rules[ruleIdentifier]() {
+					goto l54
+				}
+				add(ruleWord, position55)
+			}
+			return true
+		l54:
+			position, tokenIndex = position54, tokenIndex54
+			return false
+		},
+		/* 13 Qualifier <- <([a-z] ([a-z] / [A-Z] / ([0-9] / [0-9]))* ':')> */
+		func() bool {
+			position58, tokenIndex58 := position, tokenIndex
+			{
+				position59 := position
+				if c := buffer[position]; c < rune('a') || c > rune('z') {
+					goto l58
+				}
+				position++
+			l60:
+				{
+					position61, tokenIndex61 := position, tokenIndex
+					{
+						position62, tokenIndex62 := position, tokenIndex
+						if c := buffer[position]; c < rune('a') || c > rune('z') {
+							goto l63
+						}
+						position++
+						goto l62
+					l63:
+						position, tokenIndex = position62, tokenIndex62
+						if c := buffer[position]; c < rune('A') || c > rune('Z') {
+							goto l64
+						}
+						position++
+						goto l62
+					l64:
+						position, tokenIndex = position62, tokenIndex62
+						{
+							position65, tokenIndex65 := position, tokenIndex
+							if c := buffer[position]; c < rune('0') || c > rune('9') {
+								goto l66
+							}
+							position++
+							goto l65
+						l66:
+							position, tokenIndex = position65, tokenIndex65
+							if c := buffer[position]; c < rune('0') || c > rune('9') {
+								goto l61
+							}
+							position++
+						}
+					l65:
+					}
+				l62:
+					goto l60
+				l61:
+					position, tokenIndex = position61, tokenIndex61
+				}
+				if buffer[position] != rune(':') {
+					goto l58
+				}
+				position++
+				add(ruleQualifier, position59)
+			}
+			return true
+		l58:
+			position, tokenIndex = position58, tokenIndex58
+			return false
+		},
+		/* 14 Identifier <- <(SymbolName / DefName)> */
+		func() bool {
+			position67, tokenIndex67 := position, tokenIndex
+			{
+				position68 := position
+				{
+					position69, tokenIndex69 := position, tokenIndex
+					if !_rules[ruleSymbolName]() {
+						goto l70
+					}
+					goto l69
+				l70:
+					position, tokenIndex = position69, tokenIndex69
+					if !_rules[ruleDefName]() {
+						goto l67
+					}
+				}
+			l69:
+				add(ruleIdentifier, position68)
+			}
+			return true
+		l67:
+			position, tokenIndex = position67, tokenIndex67
+			return false
+		},
+		/* 15 SymbolName <- <(<([A-Z] ([a-z] / [A-Z] / ([0-9] / [0-9]))*)> Spacing)> */
+		func() bool {
+			position71, tokenIndex71 := position, tokenIndex
+			{
+				position72 := position
+				{
+					position73 := position
 					if c := buffer[position]; c < rune('A') || c > rune('Z') {
-						goto l12
+						goto l71
 					}
 					position++
-				l15:
+				l74:
 					{
-						position16, tokenIndex16 := position, tokenIndex
+						position75, tokenIndex75 := position, tokenIndex
 						{
-							position17, tokenIndex17 := position, tokenIndex
+							position76, tokenIndex76 := position, tokenIndex
 							if c := buffer[position]; c < rune('a') || c > rune('z') {
-								goto l18
+								goto l77
 							}
 							position++
-							goto l17
-						l18:
-							position, tokenIndex = position17, tokenIndex17
+							goto l76
+						l77:
+							position, tokenIndex = position76, tokenIndex76
 							if c := buffer[position]; c < rune('A') || c > rune('Z') {
-								goto l19
+								goto l78
 							}
 							position++
-							goto l17
-						l19:
-							position, tokenIndex = position17, tokenIndex17
+							goto l76
+						l78:
+							position, tokenIndex = position76, tokenIndex76
 							{
-								position20, tokenIndex20 := position, tokenIndex
+								position79, tokenIndex79 := position, tokenIndex
 								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l21
+									goto l80
 								}
 								position++
-								goto l20
-							l21:
-								position, tokenIndex = position20, tokenIndex20
+								goto l79
+							l80:
+								position, tokenIndex = position79, tokenIndex79
 								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l16
+									goto l75
 								}
 								position++
 							}
-						l20:
+						l79:
 						}
-					l17:
-						goto l15
-					l16:
-						position, tokenIndex = position16, tokenIndex16
+					l76:
+						goto l74
+					l75:
+						position, tokenIndex = position75, tokenIndex75
 					}
-					add(rulePegText, position14)
+					add(rulePegText, position73)
 				}
 				if !_rules[ruleSpacing]() {
-					goto l12
+					goto l71
 				}
-				add(ruleSymbolName, position13)
+				add(ruleSymbolName, position72)
 			}
 			return true
-		l12:
-			position, tokenIndex = position12, tokenIndex12
+		l71:
+			position, tokenIndex = position71, tokenIndex71
 			return false
 		},
-		/* 5 DefName <- <(<([a-z] ([a-z] / [A-Z] / ([0-9] / [0-9]))*)> Spacing)> */
+		/* 16 DefName <- <(<([a-z] ([a-z] / [A-Z] / ([0-9] / [0-9]))*)> Spacing)> */
 		func() bool {
-			position22, tokenIndex22 := position, tokenIndex
+			position81, tokenIndex81 := position, tokenIndex
 			{
-				position23 := position
+				position82 := position
 				{
-					position24 := position
+					position83 := position
 					if c := buffer[position]; c < rune('a') || c > rune('z') {
-						goto l22
+						goto l81
 					}
 					position++
-				l25:
+				l84:
 					{
-						position26, tokenIndex26 := position, tokenIndex
+						position85, tokenIndex85 := position, tokenIndex
 						{
-							position27, tokenIndex27 := position, tokenIndex
+							position86, tokenIndex86 := position, tokenIndex
 							if c := buffer[position]; c < rune('a') || c > rune('z') {
-								goto l28
+								goto l87
 							}
 							position++
-							goto l27
-						l28:
-							position, tokenIndex = position27, tokenIndex27
+							goto l86
+						l87:
+							position, tokenIndex = position86, tokenIndex86
 							if c := buffer[position]; c < rune('A') || c > rune('Z') {
-								goto l29
+								goto l88
 							}
 							position++
-							goto l27
-						l29:
-							position, tokenIndex = position27, tokenIndex27
+							goto l86
+						l88:
+							position, tokenIndex = position86, tokenIndex86
 							{
-								position30, tokenIndex30 := position, tokenIndex
+								position89, tokenIndex89 := position, tokenIndex
 								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l31
+									goto l90
 								}
 								position++
-								goto l30
-							l31:
-								position, tokenIndex = position30, tokenIndex30
+								goto l89
+							l90:
+								position, tokenIndex = position89, tokenIndex89
 								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l26
+									goto l85
 								}
 								position++
 							}
-						l30:
+						l89:
 						}
-					l27:
-						goto l25
-					l26:
-						position, tokenIndex = position26, tokenIndex26
+					l86:
+						goto l84
+					l85:
+						position, tokenIndex = position85, tokenIndex85
 					}
-					add(rulePegText, position24)
+					add(rulePegText, position83)
 				}
 				if !_rules[ruleSpacing]() {
-					goto l22
+					goto l81
 				}
-				add(ruleDefName, position23)
+				add(ruleDefName, position82)
 			}
 			return true
-		l22:
-			position, tokenIndex = position22, tokenIndex22
+		l81:
+			position, tokenIndex = position81, tokenIndex81
+			return false
+		},
+		/* 17 Equals <- <('=' Spacing)> */
+		func() bool {
+			position91, tokenIndex91 := position, tokenIndex
+			{
+				position92 := position
+				if buffer[position] != rune('=') {
+					goto l91
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l91
+				}
+				add(ruleEquals, position92)
+			}
+			return true
+		l91:
+			position, tokenIndex = position91, tokenIndex91
+			return false
+		},
+		/* 18 Period <- <('.' Spacing)> */
+		func() bool {
+			position93, tokenIndex93 := position, tokenIndex
+			{
+				position94 := position
+				if buffer[position] != rune('.') {
+					goto l93
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l93
+				}
+				add(rulePeriod, position94)
+			}
+			return true
+		l93:
+			position, tokenIndex = position93, tokenIndex93
+			return false
+		},
+		/* 19 Comma <- <(',' Spacing)> */
+		func() bool {
+			position95, tokenIndex95 := position, tokenIndex
+			{
+				position96 := position
+				if buffer[position] != rune(',') {
+					goto l95
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l95
+				}
+				add(ruleComma, position96)
+			}
+			return true
+		l95:
+			position, tokenIndex = position95, tokenIndex95
+			return false
+		},
+		/* 20 Open <- <('(' Spacing)> */
+		func() bool {
+			position97, tokenIndex97 := position, tokenIndex
+			{
+				position98 := position
+				if buffer[position] != rune('(') {
+					goto l97
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l97
+				}
+				add(ruleOpen, position98)
+			}
+			return true
+		l97:
+			position, tokenIndex = position97, tokenIndex97
 			return false
 		},
-		/* 6 Space <- <(WhiteSpace / Comment)> */
+		/* 21 Close <- <(')' Spacing)> */
 		func() bool {
-			position32, tokenIndex32 := position, tokenIndex
+			position99, tokenIndex99 := position, tokenIndex
 			{
-				position33 := position
+				position100 := position
+				if buffer[position] != rune(')') {
+					goto l99
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l99
+				}
+				add(ruleClose, position100)
+			}
+			return true
+		l99:
+			position, tokenIndex = position99, tokenIndex99
+			return false
+		},
+		/* 22 If <- <(':' '-' Spacing)> */
+		func() bool {
+			position101, tokenIndex101 := position, tokenIndex
+			{
+				position102 := position
+				if buffer[position] != rune(':') {
+					goto l101
+				}
+				position++
+				if buffer[position] != rune('-') {
+					goto l101
+				}
+				position++
+				if !_rules[ruleSpacing]() {
+					goto l101
+				}
+				add(ruleIf, position102)
+			}
+			return true
+		l101:
+			position, tokenIndex = position101, tokenIndex101
+			return false
+		},
+		/* 23 Space <- <(WhiteSpace / Comment)> */
+		func() bool {
+			position103, tokenIndex103 := position, tokenIndex
+			{
+				position104 := position
 				{
-					position34, tokenIndex34 := position, tokenIndex
+					position105, tokenIndex105 := position, tokenIndex
 					if !_rules[ruleWhiteSpace]() {
-						goto l35
+						goto l106
 					}
-					goto l34
-				l35:
-					position, tokenIndex = position34, tokenIndex34
+					goto l105
+				l106:
+					position, tokenIndex = position105, tokenIndex105
 					if !_rules[ruleComment]() {
-						goto l32
+						goto l103
 					}
 				}
-			l34:
-				add(ruleSpace, position33)
+			l105:
+				add(ruleSpace, position104)
 			}
 			return true
-		l32:
-			position, tokenIndex = position32, tokenIndex32
+		l103:
+			position, tokenIndex = position103, tokenIndex103
 			return false
 		},
-		/* 7 Spacing <- <Space*> */
+		/* 24 Spacing <- <Space*> */
 		func() bool {
 			{
-				position37 := position
-			l38:
+				position108 := position
+			l109:
 				{
-					position39, tokenIndex39 := position, tokenIndex
+					position110, tokenIndex110 := position, tokenIndex
 					if !_rules[ruleSpace]() {
-						goto l39
+						goto l110
 					}
-					goto l38
-				l39:
-					position, tokenIndex = position39, tokenIndex39
+					goto l109
+				l110:
+					position, tokenIndex = position110, tokenIndex110
 				}
-				add(ruleSpacing, position37)
+				add(ruleSpacing, position108)
 			}
 			return true
 		},
-		/* 8 WhiteSpace <- <(' ' / '\n' / '\r' / '\t')> */
+		/* 25 WhiteSpace <- <(' ' / '\n' / '\r' / '\t')> */
 		func() bool {
-			position40, tokenIndex40 := position, tokenIndex
+			position111, tokenIndex111 := position, tokenIndex
 			{
-				position41 := position
+				position112 := position
 				{
-					position42, tokenIndex42 := position, tokenIndex
+					position113, tokenIndex113 := position, tokenIndex
 					if buffer[position] != rune(' ') {
-						goto l43
+						goto l114
 					}
 					position++
-					goto l42
-				l43:
-					position, tokenIndex = position42, tokenIndex42
+					goto l113
+				l114:
+					position, tokenIndex = position113, tokenIndex113
 					if buffer[position] != rune('\n') {
-						goto l44
+						goto l115
 					}
 					position++
-					goto l42
-				l44:
-					position, tokenIndex = position42, tokenIndex42
+					goto l113
+				l115:
+					position, tokenIndex = position113, tokenIndex113
 					if buffer[position] != rune('\r') {
-						goto l45
+						goto l116
 					}
 					position++
-					goto l42
-				l45:
-					position, tokenIndex = position42, tokenIndex42
+					goto l113
+				l116:
+					position, tokenIndex = position113, tokenIndex113
 					if buffer[position] != rune('\t') {
-						goto l40
+						goto l111
 					}
 					position++
 				}
-			l42:
-				add(ruleWhiteSpace, position41)
+			l113:
+				add(ruleWhiteSpace, position112)
 			}
 			return true
-		l40:
-			position, tokenIndex = position40, tokenIndex40
+		l111:
+			position, tokenIndex = position111, tokenIndex111
 			return false
 		},
-		/* 9 Comment <- <('#' (!EndOfLine .)* EndOfLine)> */
+		/* 26 Comment <- <('#' (!EndOfLine .)* (EndOfLine / EndOfFile))> */
 		func() bool {
-			position46, tokenIndex46 := position, tokenIndex
+			position117, tokenIndex117 := position, tokenIndex
 			{
-				position47 := position
+				position118 := position
 				if buffer[position] != rune('#') {
-					goto l46
+					goto l117
 				}
 				position++
-			l48:
+			l119:
 				{
-					position49, tokenIndex49 := position, tokenIndex
+					position120, tokenIndex120 := position, tokenIndex
 					{
-						position50, tokenIndex50 := position, tokenIndex
+						position121, tokenIndex121 := position, tokenIndex
 						if !_rules[ruleEndOfLine]() {
-							goto l50
+							goto l121
 						}
-						goto l49
-					l50:
-						position, tokenIndex = position50, tokenIndex50
+						goto l120
+					l121:
+						position, tokenIndex = position121, tokenIndex121
 					}
 					if !matchDot() {
-						goto l49
+						goto l120
 					}
-					goto l48
-				l49:
-					position, tokenIndex = position49, tokenIndex49
+					goto l119
+				l120:
+					position, tokenIndex = position120, tokenIndex120
 				}
-				if !_rules[ruleEndOfLine]() {
-					goto l46
+				{
+					position122, tokenIndex122 := position, tokenIndex
+					if !_rules[ruleEndOfLine]() {
+						goto l123
+					}
+					goto l122
+				l123:
+					position, tokenIndex = position122, tokenIndex122
+					if !_rules[ruleEndOfFile]() {
+						goto l117
+					}
 				}
-				add(ruleComment, position47)
+			l122:
+				add(ruleComment, position118)
 			}
 			return true
-		l46:
-			position, tokenIndex = position46, tokenIndex46
+		l117:
+			position, tokenIndex = position117, tokenIndex117
 			return false
 		},
-		/* 10 EndOfFile <- <!.> */
+		/* 27 EndOfFile <- <!.> */
 		func() bool {
-			position51, tokenIndex51 := position, tokenIndex
+			position124, tokenIndex124 := position, tokenIndex
 			{
-				position52 := position
+				position125 := position
 				{
-					position53, tokenIndex53 := position, tokenIndex
+					position126, tokenIndex126 := position, tokenIndex
 					if !matchDot() {
-						goto l53
+						goto l126
 					}
-					goto l51
-				l53:
-					position, tokenIndex = position53, tokenIndex53
+					goto l124
+				l126:
+					position, tokenIndex = position126, tokenIndex126
 				}
-				add(ruleEndOfFile, position52)
+				add(ruleEndOfFile, position125)
 			}
 			return true
-		l51:
-			position, tokenIndex = position51, tokenIndex51
+		l124:
+			position, tokenIndex = position124, tokenIndex124
 			return false
 		},
-		/* 11 EndOfLine <- <'\n'> */
+		/* 28 EndOfLine <- <'\n'> */
 		func() bool {
-			position54, tokenIndex54 := position, tokenIndex
+			position127, tokenIndex127 := position, tokenIndex
 			{
-				position55 := position
+				position128 := position
 				if buffer[position] != rune('\n') {
-					goto l54
+					goto l127
 				}
 				position++
-				add(ruleEndOfLine, position55)
+				add(ruleEndOfLine, position128)
 			}
 			return true
-		l54:
-			position, tokenIndex = position54, tokenIndex54
+		l127:
+			position, tokenIndex = position127, tokenIndex127
 			return false
 		},
 		nil,
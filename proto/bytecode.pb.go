@@ -8,6 +8,7 @@ It is generated from these files:
 	proto/bytecode.proto
 
 It has these top-level messages:
+	Module
 	Operation
 	Push
 	Permute
@@ -15,6 +16,24 @@ It has these top-level messages:
 	Ungroup
 	Commit
 	Recall
+	Label
+	Jump
+	Branch
+	Call
+	Return
+	Choice
+	Fail
+	Fresh
+	Unify
+	DebugInfo
+	Position
+	Snapshot
+	Term
+	TreeTerm
+	VarTerm
+	ChoicePoint
+	Value
+	Tree
 */
 package bytecode
 
@@ -33,19 +52,71 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type Module struct {
+	Package string       `protobuf:"bytes,1,opt,name=package" json:"package,omitempty"`
+	Symbols []string     `protobuf:"bytes,2,rep,name=symbols" json:"symbols,omitempty"`
+	Code    []*Operation `protobuf:"bytes,3,rep,name=code" json:"code,omitempty"`
+	// debugInfo, if set, maps code back to the source it was compiled from.
+	DebugInfo *DebugInfo `protobuf:"bytes,4,opt,name=debugInfo" json:"debugInfo,omitempty"`
+}
+
+func (m *Module) Reset()                    { *m = Module{} }
+func (m *Module) String() string            { return proto.CompactTextString(m) }
+func (*Module) ProtoMessage()               {}
+func (*Module) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *Module) GetPackage() string {
+	if m != nil {
+		return m.Package
+	}
+	return ""
+}
+
+func (m *Module) GetSymbols() []string {
+	if m != nil {
+		return m.Symbols
+	}
+	return nil
+}
+
+func (m *Module) GetCode() []*Operation {
+	if m != nil {
+		return m.Code
+	}
+	return nil
+}
+
+func (m *Module) GetDebugInfo() *DebugInfo {
+	if m != nil {
+		return m.DebugInfo
+	}
+	return nil
+}
+
 type Operation struct {
 	// Types that are valid to be assigned to Op:
 	//	*Operation_Push
 	//	*Operation_Permute
 	//	*Operation_Commit
 	//	*Operation_Recall
+	//	*Operation_Group
+	//	*Operation_Ungroup
+	//	*Operation_Label
+	//	*Operation_Jump
+	//	*Operation_Branch
+	//	*Operation_Call
+	//	*Operation_Return
+	//	*Operation_Choice
+	//	*Operation_Fail
+	//	*Operation_Fresh
+	//	*Operation_Unify
 	Op isOperation_Op `protobuf_oneof:"op"`
 }
 
 func (m *Operation) Reset()                    { *m = Operation{} }
 func (m *Operation) String() string            { return proto.CompactTextString(m) }
 func (*Operation) ProtoMessage()               {}
-func (*Operation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+func (*Operation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
 type isOperation_Op interface {
 	isOperation_Op()
@@ -63,11 +134,55 @@ type Operation_Commit struct {
 type Operation_Recall struct {
 	Recall *Recall `protobuf:"bytes,4,opt,name=recall,oneof"`
 }
+type Operation_Group struct {
+	Group *Group `protobuf:"bytes,5,opt,name=group,oneof"`
+}
+type Operation_Ungroup struct {
+	Ungroup *Ungroup `protobuf:"bytes,6,opt,name=ungroup,oneof"`
+}
+type Operation_Label struct {
+	Label *Label `protobuf:"bytes,7,opt,name=label,oneof"`
+}
+type Operation_Jump struct {
+	Jump *Jump `protobuf:"bytes,8,opt,name=jump,oneof"`
+}
+type Operation_Branch struct {
+	Branch *Branch `protobuf:"bytes,9,opt,name=branch,oneof"`
+}
+type Operation_Call struct {
+	Call *Call `protobuf:"bytes,10,opt,name=call,oneof"`
+}
+type Operation_Return struct {
+	Return *Return `protobuf:"bytes,11,opt,name=return,oneof"`
+}
+type Operation_Choice struct {
+	Choice *Choice `protobuf:"bytes,12,opt,name=choice,oneof"`
+}
+type Operation_Fail struct {
+	Fail *Fail `protobuf:"bytes,13,opt,name=fail,oneof"`
+}
+type Operation_Fresh struct {
+	Fresh *Fresh `protobuf:"bytes,14,opt,name=fresh,oneof"`
+}
+type Operation_Unify struct {
+	Unify *Unify `protobuf:"bytes,15,opt,name=unify,oneof"`
+}
 
 func (*Operation_Push) isOperation_Op()    {}
 func (*Operation_Permute) isOperation_Op() {}
 func (*Operation_Commit) isOperation_Op()  {}
 func (*Operation_Recall) isOperation_Op()  {}
+func (*Operation_Group) isOperation_Op()   {}
+func (*Operation_Ungroup) isOperation_Op() {}
+func (*Operation_Label) isOperation_Op()   {}
+func (*Operation_Jump) isOperation_Op()    {}
+func (*Operation_Branch) isOperation_Op()  {}
+func (*Operation_Call) isOperation_Op()    {}
+func (*Operation_Return) isOperation_Op()  {}
+func (*Operation_Choice) isOperation_Op()  {}
+func (*Operation_Fail) isOperation_Op()    {}
+func (*Operation_Fresh) isOperation_Op()   {}
+func (*Operation_Unify) isOperation_Op()   {}
 
 func (m *Operation) GetOp() isOperation_Op {
 	if m != nil {
@@ -104,6 +219,83 @@ func (m *Operation) GetRecall() *Recall {
 	return nil
 }
 
+func (m *Operation) GetGroup() *Group {
+	if x, ok := m.GetOp().(*Operation_Group); ok {
+		return x.Group
+	}
+	return nil
+}
+
+func (m *Operation) GetUngroup() *Ungroup {
+	if x, ok := m.GetOp().(*Operation_Ungroup); ok {
+		return x.Ungroup
+	}
+	return nil
+}
+
+func (m *Operation) GetLabel() *Label {
+	if x, ok := m.GetOp().(*Operation_Label); ok {
+		return x.Label
+	}
+	return nil
+}
+
+func (m *Operation) GetJump() *Jump {
+	if x, ok := m.GetOp().(*Operation_Jump); ok {
+		return x.Jump
+	}
+	return nil
+}
+
+func (m *Operation) GetBranch() *Branch {
+	if x, ok := m.GetOp().(*Operation_Branch); ok {
+		return x.Branch
+	}
+	return nil
+}
+
+func (m *Operation) GetCall() *Call {
+	if x, ok := m.GetOp().(*Operation_Call); ok {
+		return x.Call
+	}
+	return nil
+}
+
+func (m *Operation) GetReturn() *Return {
+	if x, ok := m.GetOp().(*Operation_Return); ok {
+		return x.Return
+	}
+	return nil
+}
+
+func (m *Operation) GetChoice() *Choice {
+	if x, ok := m.GetOp().(*Operation_Choice); ok {
+		return x.Choice
+	}
+	return nil
+}
+
+func (m *Operation) GetFail() *Fail {
+	if x, ok := m.GetOp().(*Operation_Fail); ok {
+		return x.Fail
+	}
+	return nil
+}
+
+func (m *Operation) GetFresh() *Fresh {
+	if x, ok := m.GetOp().(*Operation_Fresh); ok {
+		return x.Fresh
+	}
+	return nil
+}
+
+func (m *Operation) GetUnify() *Unify {
+	if x, ok := m.GetOp().(*Operation_Unify); ok {
+		return x.Unify
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Operation) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Operation_OneofMarshaler, _Operation_OneofUnmarshaler, _Operation_OneofSizer, []interface{}{
@@ -111,6 +303,17 @@ func (*Operation) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) err
 		(*Operation_Permute)(nil),
 		(*Operation_Commit)(nil),
 		(*Operation_Recall)(nil),
+		(*Operation_Group)(nil),
+		(*Operation_Ungroup)(nil),
+		(*Operation_Label)(nil),
+		(*Operation_Jump)(nil),
+		(*Operation_Branch)(nil),
+		(*Operation_Call)(nil),
+		(*Operation_Return)(nil),
+		(*Operation_Choice)(nil),
+		(*Operation_Fail)(nil),
+		(*Operation_Fresh)(nil),
+		(*Operation_Unify)(nil),
 	}
 }
 
@@ -138,6 +341,61 @@ func _Operation_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Recall); err != nil {
 			return err
 		}
+	case *Operation_Group:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Group); err != nil {
+			return err
+		}
+	case *Operation_Ungroup:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Ungroup); err != nil {
+			return err
+		}
+	case *Operation_Label:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Label); err != nil {
+			return err
+		}
+	case *Operation_Jump:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Jump); err != nil {
+			return err
+		}
+	case *Operation_Branch:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Branch); err != nil {
+			return err
+		}
+	case *Operation_Call:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Call); err != nil {
+			return err
+		}
+	case *Operation_Return:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Return); err != nil {
+			return err
+		}
+	case *Operation_Choice:
+		b.EncodeVarint(12<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Choice); err != nil {
+			return err
+		}
+	case *Operation_Fail:
+		b.EncodeVarint(13<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Fail); err != nil {
+			return err
+		}
+	case *Operation_Fresh:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Fresh); err != nil {
+			return err
+		}
+	case *Operation_Unify:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Unify); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Operation.Op has unexpected type %T", x)
@@ -180,6 +438,94 @@ func _Operation_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buff
 		err := b.DecodeMessage(msg)
 		m.Op = &Operation_Recall{msg}
 		return true, err
+	case 5: // op.group
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Group)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Group{msg}
+		return true, err
+	case 6: // op.ungroup
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Ungroup)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Ungroup{msg}
+		return true, err
+	case 7: // op.label
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Label)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Label{msg}
+		return true, err
+	case 8: // op.jump
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Jump)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Jump{msg}
+		return true, err
+	case 9: // op.branch
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Branch)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Branch{msg}
+		return true, err
+	case 10: // op.call
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Call)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Call{msg}
+		return true, err
+	case 11: // op.return
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Return)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Return{msg}
+		return true, err
+	case 12: // op.choice
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Choice)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Choice{msg}
+		return true, err
+	case 13: // op.fail
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Fail)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Fail{msg}
+		return true, err
+	case 14: // op.fresh
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Fresh)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Fresh{msg}
+		return true, err
+	case 15: // op.unify
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Unify)
+		err := b.DecodeMessage(msg)
+		m.Op = &Operation_Unify{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -209,6 +555,61 @@ func _Operation_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(4<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Operation_Group:
+		s := proto.Size(x.Group)
+		n += proto.SizeVarint(5<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Ungroup:
+		s := proto.Size(x.Ungroup)
+		n += proto.SizeVarint(6<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Label:
+		s := proto.Size(x.Label)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Jump:
+		s := proto.Size(x.Jump)
+		n += proto.SizeVarint(8<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Branch:
+		s := proto.Size(x.Branch)
+		n += proto.SizeVarint(9<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Call:
+		s := proto.Size(x.Call)
+		n += proto.SizeVarint(10<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Return:
+		s := proto.Size(x.Return)
+		n += proto.SizeVarint(11<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Choice:
+		s := proto.Size(x.Choice)
+		n += proto.SizeVarint(12<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Fail:
+		s := proto.Size(x.Fail)
+		n += proto.SizeVarint(13<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Fresh:
+		s := proto.Size(x.Fresh)
+		n += proto.SizeVarint(14<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Operation_Unify:
+		s := proto.Size(x.Unify)
+		n += proto.SizeVarint(15<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -223,7 +624,7 @@ type Push struct {
 func (m *Push) Reset()                    { *m = Push{} }
 func (m *Push) String() string            { return proto.CompactTextString(m) }
 func (*Push) ProtoMessage()               {}
-func (*Push) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*Push) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *Push) GetSymbolIdx() int32 {
 	if m != nil {
@@ -240,7 +641,7 @@ type Permute struct {
 func (m *Permute) Reset()                    { *m = Permute{} }
 func (m *Permute) String() string            { return proto.CompactTextString(m) }
 func (*Permute) ProtoMessage()               {}
-func (*Permute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*Permute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 func (m *Permute) GetPop() int32 {
 	if m != nil {
@@ -263,7 +664,7 @@ type Group struct {
 func (m *Group) Reset()                    { *m = Group{} }
 func (m *Group) String() string            { return proto.CompactTextString(m) }
 func (*Group) ProtoMessage()               {}
-func (*Group) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*Group) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
 func (m *Group) GetCount() int32 {
 	if m != nil {
@@ -279,7 +680,7 @@ type Ungroup struct {
 func (m *Ungroup) Reset()                    { *m = Ungroup{} }
 func (m *Ungroup) String() string            { return proto.CompactTextString(m) }
 func (*Ungroup) ProtoMessage()               {}
-func (*Ungroup) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*Ungroup) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *Ungroup) GetCount() int32 {
 	if m != nil {
@@ -294,7 +695,7 @@ type Commit struct {
 func (m *Commit) Reset()                    { *m = Commit{} }
 func (m *Commit) String() string            { return proto.CompactTextString(m) }
 func (*Commit) ProtoMessage()               {}
-func (*Commit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*Commit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 type Recall struct {
 	Index int32 `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
@@ -303,7 +704,7 @@ type Recall struct {
 func (m *Recall) Reset()                    { *m = Recall{} }
 func (m *Recall) String() string            { return proto.CompactTextString(m) }
 func (*Recall) ProtoMessage()               {}
-func (*Recall) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*Recall) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *Recall) GetIndex() int32 {
 	if m != nil {
@@ -312,7 +713,711 @@ func (m *Recall) GetIndex() int32 {
 	return 0
 }
 
+// Label marks the start of a named block of code. It does nothing when
+// evaluated.
+type Label struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *Label) Reset()                    { *m = Label{} }
+func (m *Label) String() string            { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()               {}
+func (*Label) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *Label) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type Jump struct {
+	Target int32 `protobuf:"varint,1,opt,name=target" json:"target,omitempty"`
+}
+
+func (m *Jump) Reset()                    { *m = Jump{} }
+func (m *Jump) String() string            { return proto.CompactTextString(m) }
+func (*Jump) ProtoMessage()               {}
+func (*Jump) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *Jump) GetTarget() int32 {
+	if m != nil {
+		return m.Target
+	}
+	return 0
+}
+
+// Branch pops two symbols off the stack and jumps to target if they are equal.
+type Branch struct {
+	Target int32 `protobuf:"varint,1,opt,name=target" json:"target,omitempty"`
+}
+
+func (m *Branch) Reset()                    { *m = Branch{} }
+func (m *Branch) String() string            { return proto.CompactTextString(m) }
+func (*Branch) ProtoMessage()               {}
+func (*Branch) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *Branch) GetTarget() int32 {
+	if m != nil {
+		return m.Target
+	}
+	return 0
+}
+
+type Call struct {
+	Target int32 `protobuf:"varint,1,opt,name=target" json:"target,omitempty"`
+}
+
+func (m *Call) Reset()                    { *m = Call{} }
+func (m *Call) String() string            { return proto.CompactTextString(m) }
+func (*Call) ProtoMessage()               {}
+func (*Call) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *Call) GetTarget() int32 {
+	if m != nil {
+		return m.Target
+	}
+	return 0
+}
+
+type Return struct {
+}
+
+func (m *Return) Reset()                    { *m = Return{} }
+func (m *Return) String() string            { return proto.CompactTextString(m) }
+func (*Return) ProtoMessage()               {}
+func (*Return) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+// Choice records a choice point. If execution later fails, the stack, log
+// and call stack are restored to their state at the Choice and execution
+// resumes at target.
+type Choice struct {
+	Target int32 `protobuf:"varint,1,opt,name=target" json:"target,omitempty"`
+}
+
+func (m *Choice) Reset()                    { *m = Choice{} }
+func (m *Choice) String() string            { return proto.CompactTextString(m) }
+func (*Choice) ProtoMessage()               {}
+func (*Choice) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *Choice) GetTarget() int32 {
+	if m != nil {
+		return m.Target
+	}
+	return 0
+}
+
+// Fail backtracks to the most recent choice point.
+type Fail struct {
+}
+
+func (m *Fail) Reset()                    { *m = Fail{} }
+func (m *Fail) String() string            { return proto.CompactTextString(m) }
+func (*Fail) ProtoMessage()               {}
+func (*Fail) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+// Fresh pushes a new unbound variable.
+type Fresh struct {
+}
+
+func (m *Fresh) Reset()                    { *m = Fresh{} }
+func (m *Fresh) String() string            { return proto.CompactTextString(m) }
+func (*Fresh) ProtoMessage()               {}
+func (*Fresh) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+// Unify pops two values off the stack and unifies them, binding variables as
+// needed. If they don't unify, it fails like Fail.
+type Unify struct {
+}
+
+func (m *Unify) Reset()                    { *m = Unify{} }
+func (m *Unify) String() string            { return proto.CompactTextString(m) }
+func (*Unify) ProtoMessage()               {}
+func (*Unify) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+// DebugInfo maps a Module's code back to the source it was compiled from.
+type DebugInfo struct {
+	// files holds the names of the source files.
+	Files []string `protobuf:"bytes,1,rep,name=files" json:"files,omitempty"`
+	// positions holds the source position of each operation, by index into
+	// the code. It may be shorter than the code, leaving the positions of the
+	// remaining operations unknown.
+	Positions []*Position `protobuf:"bytes,2,rep,name=positions" json:"positions,omitempty"`
+}
+
+func (m *DebugInfo) Reset()                    { *m = DebugInfo{} }
+func (m *DebugInfo) String() string            { return proto.CompactTextString(m) }
+func (*DebugInfo) ProtoMessage()               {}
+func (*DebugInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *DebugInfo) GetFiles() []string {
+	if m != nil {
+		return m.Files
+	}
+	return nil
+}
+
+func (m *DebugInfo) GetPositions() []*Position {
+	if m != nil {
+		return m.Positions
+	}
+	return nil
+}
+
+// Position is a position in a source file. A line of 0 means the position is
+// unknown.
+type Position struct {
+	// fileIdx is the index of the file in DebugInfo.files.
+	FileIdx int32 `protobuf:"varint,1,opt,name=fileIdx" json:"fileIdx,omitempty"`
+	// line and column count from 1.
+	Line   int32 `protobuf:"varint,2,opt,name=line" json:"line,omitempty"`
+	Column int32 `protobuf:"varint,3,opt,name=column" json:"column,omitempty"`
+}
+
+func (m *Position) Reset()                    { *m = Position{} }
+func (m *Position) String() string            { return proto.CompactTextString(m) }
+func (*Position) ProtoMessage()               {}
+func (*Position) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *Position) GetFileIdx() int32 {
+	if m != nil {
+		return m.FileIdx
+	}
+	return 0
+}
+
+func (m *Position) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *Position) GetColumn() int32 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+// Snapshot is the state of a runtime, saved so evaluation can be resumed
+// later. Values are stored once each in terms, and referred to elsewhere by
+// their index there, so that values shared in the runtime, and variables in
+// particular, are shared again when it's restored.
+type Snapshot struct {
+	Terms     []*Term        `protobuf:"bytes,1,rep,name=terms" json:"terms,omitempty"`
+	Stack     []int32        `protobuf:"varint,2,rep,packed,name=stack" json:"stack,omitempty"`
+	Log       []int32        `protobuf:"varint,3,rep,packed,name=log" json:"log,omitempty"`
+	Pc        int32          `protobuf:"varint,4,opt,name=pc" json:"pc,omitempty"`
+	CallStack []int32        `protobuf:"varint,5,rep,packed,name=callStack" json:"callStack,omitempty"`
+	Choices   []*ChoicePoint `protobuf:"bytes,6,rep,name=choices" json:"choices,omitempty"`
+	// trail holds the variables bound since execution started, in the order
+	// they were bound.
+	Trail []int32 `protobuf:"varint,7,rep,packed,name=trail" json:"trail,omitempty"`
+	// vars counts the variables created.
+	Vars int32 `protobuf:"varint,8,opt,name=vars" json:"vars,omitempty"`
+}
+
+func (m *Snapshot) Reset()                    { *m = Snapshot{} }
+func (m *Snapshot) String() string            { return proto.CompactTextString(m) }
+func (*Snapshot) ProtoMessage()               {}
+func (*Snapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *Snapshot) GetTerms() []*Term {
+	if m != nil {
+		return m.Terms
+	}
+	return nil
+}
+
+func (m *Snapshot) GetStack() []int32 {
+	if m != nil {
+		return m.Stack
+	}
+	return nil
+}
+
+func (m *Snapshot) GetLog() []int32 {
+	if m != nil {
+		return m.Log
+	}
+	return nil
+}
+
+func (m *Snapshot) GetPc() int32 {
+	if m != nil {
+		return m.Pc
+	}
+	return 0
+}
+
+func (m *Snapshot) GetCallStack() []int32 {
+	if m != nil {
+		return m.CallStack
+	}
+	return nil
+}
+
+func (m *Snapshot) GetChoices() []*ChoicePoint {
+	if m != nil {
+		return m.Choices
+	}
+	return nil
+}
+
+func (m *Snapshot) GetTrail() []int32 {
+	if m != nil {
+		return m.Trail
+	}
+	return nil
+}
+
+func (m *Snapshot) GetVars() int32 {
+	if m != nil {
+		return m.Vars
+	}
+	return 0
+}
+
+// Term is a value in a Snapshot.
+type Term struct {
+	// Types that are valid to be assigned to Term:
+	//	*Term_Symbol
+	//	*Term_Tree
+	//	*Term_Var
+	Term isTerm_Term `protobuf_oneof:"term"`
+}
+
+func (m *Term) Reset()                    { *m = Term{} }
+func (m *Term) String() string            { return proto.CompactTextString(m) }
+func (*Term) ProtoMessage()               {}
+func (*Term) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+type isTerm_Term interface {
+	isTerm_Term()
+}
+
+type Term_Symbol struct {
+	Symbol int32 `protobuf:"varint,1,opt,name=symbol,oneof"`
+}
+type Term_Tree struct {
+	Tree *TreeTerm `protobuf:"bytes,2,opt,name=tree,oneof"`
+}
+type Term_Var struct {
+	Var *VarTerm `protobuf:"bytes,3,opt,name=var,oneof"`
+}
+
+func (*Term_Symbol) isTerm_Term() {}
+func (*Term_Tree) isTerm_Term()   {}
+func (*Term_Var) isTerm_Term()    {}
+
+func (m *Term) GetTerm() isTerm_Term {
+	if m != nil {
+		return m.Term
+	}
+	return nil
+}
+
+func (m *Term) GetSymbol() int32 {
+	if x, ok := m.GetTerm().(*Term_Symbol); ok {
+		return x.Symbol
+	}
+	return 0
+}
+
+func (m *Term) GetTree() *TreeTerm {
+	if x, ok := m.GetTerm().(*Term_Tree); ok {
+		return x.Tree
+	}
+	return nil
+}
+
+func (m *Term) GetVar() *VarTerm {
+	if x, ok := m.GetTerm().(*Term_Var); ok {
+		return x.Var
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Term) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Term_OneofMarshaler, _Term_OneofUnmarshaler, _Term_OneofSizer, []interface{}{
+		(*Term_Symbol)(nil),
+		(*Term_Tree)(nil),
+		(*Term_Var)(nil),
+	}
+}
+
+func _Term_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Term)
+	// term
+	switch x := m.Term.(type) {
+	case *Term_Symbol:
+		b.EncodeVarint(1<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.Symbol))
+	case *Term_Tree:
+		b.EncodeVarint(2<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Tree); err != nil {
+			return err
+		}
+	case *Term_Var:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Var); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("Term.Term has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Term_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Term)
+	switch tag {
+	case 1: // term.symbol
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Term = &Term_Symbol{int32(x)}
+		return true, err
+	case 2: // term.tree
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(TreeTerm)
+		err := b.DecodeMessage(msg)
+		m.Term = &Term_Tree{msg}
+		return true, err
+	case 3: // term.var
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(VarTerm)
+		err := b.DecodeMessage(msg)
+		m.Term = &Term_Var{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Term_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Term)
+	// term
+	switch x := m.Term.(type) {
+	case *Term_Symbol:
+		n += proto.SizeVarint(1<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.Symbol))
+	case *Term_Tree:
+		s := proto.Size(x.Tree)
+		n += proto.SizeVarint(2<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Term_Var:
+		s := proto.Size(x.Var)
+		n += proto.SizeVarint(3<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// TreeTerm is a tree, whose children are given by their indices in
+// Snapshot.terms.
+type TreeTerm struct {
+	Children []int32 `protobuf:"varint,1,rep,packed,name=children" json:"children,omitempty"`
+}
+
+func (m *TreeTerm) Reset()                    { *m = TreeTerm{} }
+func (m *TreeTerm) String() string            { return proto.CompactTextString(m) }
+func (*TreeTerm) ProtoMessage()               {}
+func (*TreeTerm) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *TreeTerm) GetChildren() []int32 {
+	if m != nil {
+		return m.Children
+	}
+	return nil
+}
+
+// VarTerm is a logic variable.
+type VarTerm struct {
+	Id int32 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	// bound is set if the variable is bound to the term at index binding.
+	Bound   bool  `protobuf:"varint,2,opt,name=bound" json:"bound,omitempty"`
+	Binding int32 `protobuf:"varint,3,opt,name=binding" json:"binding,omitempty"`
+}
+
+func (m *VarTerm) Reset()                    { *m = VarTerm{} }
+func (m *VarTerm) String() string            { return proto.CompactTextString(m) }
+func (*VarTerm) ProtoMessage()               {}
+func (*VarTerm) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *VarTerm) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *VarTerm) GetBound() bool {
+	if m != nil {
+		return m.Bound
+	}
+	return false
+}
+
+func (m *VarTerm) GetBinding() int32 {
+	if m != nil {
+		return m.Binding
+	}
+	return 0
+}
+
+// ChoicePoint is the state saved by a Choice operation, to backtrack to.
+type ChoicePoint struct {
+	Pc        int32   `protobuf:"varint,1,opt,name=pc" json:"pc,omitempty"`
+	Stack     []int32 `protobuf:"varint,2,rep,packed,name=stack" json:"stack,omitempty"`
+	LogLen    int32   `protobuf:"varint,3,opt,name=logLen" json:"logLen,omitempty"`
+	CallStack []int32 `protobuf:"varint,4,rep,packed,name=callStack" json:"callStack,omitempty"`
+	TrailLen  int32   `protobuf:"varint,5,opt,name=trailLen" json:"trailLen,omitempty"`
+}
+
+func (m *ChoicePoint) Reset()                    { *m = ChoicePoint{} }
+func (m *ChoicePoint) String() string            { return proto.CompactTextString(m) }
+func (*ChoicePoint) ProtoMessage()               {}
+func (*ChoicePoint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *ChoicePoint) GetPc() int32 {
+	if m != nil {
+		return m.Pc
+	}
+	return 0
+}
+
+func (m *ChoicePoint) GetStack() []int32 {
+	if m != nil {
+		return m.Stack
+	}
+	return nil
+}
+
+func (m *ChoicePoint) GetLogLen() int32 {
+	if m != nil {
+		return m.LogLen
+	}
+	return 0
+}
+
+func (m *ChoicePoint) GetCallStack() []int32 {
+	if m != nil {
+		return m.CallStack
+	}
+	return nil
+}
+
+func (m *ChoicePoint) GetTrailLen() int32 {
+	if m != nil {
+		return m.TrailLen
+	}
+	return 0
+}
+
+// Value is a value stored on its own, such as a solution or an entry of the
+// log. Bound variables are replaced by their bindings.
+type Value struct {
+	// Types that are valid to be assigned to Value:
+	//	*Value_SymbolIdx
+	//	*Value_Symbol
+	//	*Value_Tree
+	//	*Value_Var
+	Value isValue_Value `protobuf_oneof:"value"`
+}
+
+func (m *Value) Reset()                    { *m = Value{} }
+func (m *Value) String() string            { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()               {}
+func (*Value) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+type isValue_Value interface {
+	isValue_Value()
+}
+
+type Value_SymbolIdx struct {
+	SymbolIdx int32 `protobuf:"varint,1,opt,name=symbolIdx,oneof"`
+}
+type Value_Symbol struct {
+	Symbol string `protobuf:"bytes,2,opt,name=symbol,oneof"`
+}
+type Value_Tree struct {
+	Tree *Tree `protobuf:"bytes,3,opt,name=tree,oneof"`
+}
+type Value_Var struct {
+	Var int32 `protobuf:"varint,4,opt,name=var,oneof"`
+}
+
+func (*Value_SymbolIdx) isValue_Value() {}
+func (*Value_Symbol) isValue_Value()    {}
+func (*Value_Tree) isValue_Value()      {}
+func (*Value_Var) isValue_Value()       {}
+
+func (m *Value) GetValue() isValue_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Value) GetSymbolIdx() int32 {
+	if x, ok := m.GetValue().(*Value_SymbolIdx); ok {
+		return x.SymbolIdx
+	}
+	return 0
+}
+
+func (m *Value) GetSymbol() string {
+	if x, ok := m.GetValue().(*Value_Symbol); ok {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (m *Value) GetTree() *Tree {
+	if x, ok := m.GetValue().(*Value_Tree); ok {
+		return x.Tree
+	}
+	return nil
+}
+
+func (m *Value) GetVar() int32 {
+	if x, ok := m.GetValue().(*Value_Var); ok {
+		return x.Var
+	}
+	return 0
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Value) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Value_OneofMarshaler, _Value_OneofUnmarshaler, _Value_OneofSizer, []interface{}{
+		(*Value_SymbolIdx)(nil),
+		(*Value_Symbol)(nil),
+		(*Value_Tree)(nil),
+		(*Value_Var)(nil),
+	}
+}
+
+func _Value_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Value)
+	// value
+	switch x := m.Value.(type) {
+	case *Value_SymbolIdx:
+		b.EncodeVarint(1<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.SymbolIdx))
+	case *Value_Symbol:
+		b.EncodeVarint(2<<3 | proto.WireBytes)
+		b.EncodeStringBytes(x.Symbol)
+	case *Value_Tree:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Tree); err != nil {
+			return err
+		}
+	case *Value_Var:
+		b.EncodeVarint(4<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.Var))
+	case nil:
+	default:
+		return fmt.Errorf("Value.Value has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Value_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Value)
+	switch tag {
+	case 1: // value.symbolIdx
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Value = &Value_SymbolIdx{int32(x)}
+		return true, err
+	case 2: // value.symbol
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Value = &Value_Symbol{x}
+		return true, err
+	case 3: // value.tree
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Tree)
+		err := b.DecodeMessage(msg)
+		m.Value = &Value_Tree{msg}
+		return true, err
+	case 4: // value.var
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Value = &Value_Var{int32(x)}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Value_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Value)
+	// value
+	switch x := m.Value.(type) {
+	case *Value_SymbolIdx:
+		n += proto.SizeVarint(1<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.SymbolIdx))
+	case *Value_Symbol:
+		n += proto.SizeVarint(2<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(len(x.Symbol)))
+		n += len(x.Symbol)
+	case *Value_Tree:
+		s := proto.Size(x.Tree)
+		n += proto.SizeVarint(3<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Value_Var:
+		n += proto.SizeVarint(4<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.Var))
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// Tree is a tree of values.
+type Tree struct {
+	Children []*Value `protobuf:"bytes,1,rep,name=children" json:"children,omitempty"`
+}
+
+func (m *Tree) Reset()                    { *m = Tree{} }
+func (m *Tree) String() string            { return proto.CompactTextString(m) }
+func (*Tree) ProtoMessage()               {}
+func (*Tree) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *Tree) GetChildren() []*Value {
+	if m != nil {
+		return m.Children
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterType((*Module)(nil), "bytecode.Module")
 	proto.RegisterType((*Operation)(nil), "bytecode.Operation")
 	proto.RegisterType((*Push)(nil), "bytecode.Push")
 	proto.RegisterType((*Permute)(nil), "bytecode.Permute")
@@ -320,27 +1425,88 @@ func init() {
 	proto.RegisterType((*Ungroup)(nil), "bytecode.Ungroup")
 	proto.RegisterType((*Commit)(nil), "bytecode.Commit")
 	proto.RegisterType((*Recall)(nil), "bytecode.Recall")
+	proto.RegisterType((*Label)(nil), "bytecode.Label")
+	proto.RegisterType((*Jump)(nil), "bytecode.Jump")
+	proto.RegisterType((*Branch)(nil), "bytecode.Branch")
+	proto.RegisterType((*Call)(nil), "bytecode.Call")
+	proto.RegisterType((*Return)(nil), "bytecode.Return")
+	proto.RegisterType((*Choice)(nil), "bytecode.Choice")
+	proto.RegisterType((*Fail)(nil), "bytecode.Fail")
+	proto.RegisterType((*Fresh)(nil), "bytecode.Fresh")
+	proto.RegisterType((*Unify)(nil), "bytecode.Unify")
+	proto.RegisterType((*DebugInfo)(nil), "bytecode.DebugInfo")
+	proto.RegisterType((*Position)(nil), "bytecode.Position")
+	proto.RegisterType((*Snapshot)(nil), "bytecode.Snapshot")
+	proto.RegisterType((*Term)(nil), "bytecode.Term")
+	proto.RegisterType((*TreeTerm)(nil), "bytecode.TreeTerm")
+	proto.RegisterType((*VarTerm)(nil), "bytecode.VarTerm")
+	proto.RegisterType((*ChoicePoint)(nil), "bytecode.ChoicePoint")
+	proto.RegisterType((*Value)(nil), "bytecode.Value")
+	proto.RegisterType((*Tree)(nil), "bytecode.Tree")
 }
 
 func init() { proto.RegisterFile("proto/bytecode.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 266 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0xd1, 0xcb, 0x4a, 0xc3, 0x40,
-	0x14, 0x06, 0xe0, 0xdc, 0xd3, 0x1e, 0x41, 0xea, 0xd0, 0x45, 0x16, 0x5e, 0xca, 0xd0, 0x45, 0x11,
-	0x6c, 0x41, 0xdf, 0x40, 0x17, 0xc6, 0x95, 0x32, 0xe0, 0x03, 0x34, 0xc9, 0x60, 0x03, 0x49, 0xce,
-	0x30, 0x99, 0x01, 0xfb, 0x72, 0x3e, 0x9b, 0xcc, 0x25, 0x56, 0x5c, 0xb8, 0xcb, 0x7f, 0xe6, 0x3b,
-	0xf0, 0x1f, 0x02, 0x4b, 0x21, 0x51, 0xe1, 0xae, 0x3a, 0x2a, 0x5e, 0x63, 0xc3, 0xb7, 0x36, 0x92,
-	0xd9, 0x94, 0xe9, 0x57, 0x08, 0xf3, 0x57, 0xc1, 0xe5, 0x5e, 0xb5, 0x38, 0x90, 0x35, 0x24, 0x42,
-	0x8f, 0x87, 0x22, 0x5c, 0x85, 0x9b, 0xb3, 0xfb, 0xf3, 0xed, 0xcf, 0xda, 0x9b, 0x1e, 0x0f, 0x65,
-	0xc0, 0xec, 0x2b, 0xb9, 0x83, 0x5c, 0x70, 0xd9, 0x6b, 0xc5, 0x8b, 0xc8, 0xc2, 0x8b, 0x5f, 0xd0,
-	0x3d, 0x94, 0x01, 0x9b, 0x0c, 0xb9, 0x85, 0xac, 0xc6, 0xbe, 0x6f, 0x55, 0x11, 0x5b, 0xbd, 0x38,
-	0xe9, 0x27, 0x3b, 0x2f, 0x03, 0xe6, 0x85, 0xb1, 0x92, 0xd7, 0xfb, 0xae, 0x2b, 0x92, 0xbf, 0x96,
-	0xd9, 0xb9, 0xb1, 0x4e, 0x3c, 0x26, 0x10, 0xa1, 0xa0, 0x6b, 0x48, 0x4c, 0x39, 0x72, 0x09, 0xf3,
-	0xf1, 0xd8, 0x57, 0xd8, 0xbd, 0x34, 0x9f, 0xb6, 0x7f, 0xca, 0x4e, 0x03, 0xba, 0x83, 0xdc, 0x37,
-	0x23, 0x0b, 0x88, 0x05, 0x0a, 0x4f, 0xcc, 0x27, 0x21, 0xfe, 0xea, 0x68, 0x15, 0x6f, 0x52, 0x77,
-	0x23, 0xbd, 0x82, 0xf4, 0x59, 0xa2, 0x16, 0x64, 0x09, 0x69, 0x8d, 0x7a, 0x50, 0x7e, 0xc1, 0x05,
-	0x7a, 0x03, 0xf9, 0xfb, 0xf0, 0xf1, 0x0f, 0x98, 0x41, 0xe6, 0x8e, 0xa3, 0xd7, 0x90, 0xb9, 0xea,
-	0x46, 0xb6, 0x43, 0xc3, 0xa7, 0x7a, 0x2e, 0x54, 0x99, 0xfd, 0x25, 0x0f, 0xdf, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0x0f, 0x7e, 0x02, 0xc7, 0xaa, 0x01, 0x00, 0x00,
+	// 949 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x56, 0xef, 0x6e, 0xdc, 0x44,
+	0x10, 0xbf, 0x3f, 0x5e, 0xdb, 0x37, 0x07, 0x69, 0x59, 0x4a, 0xb5, 0x2a, 0x10, 0x4e, 0xab, 0x40,
+	0x23, 0x10, 0x0d, 0xa4, 0x6f, 0x90, 0xa2, 0x72, 0x41, 0x45, 0x44, 0x9b, 0xb6, 0xdf, 0x7d, 0xf6,
+	0xde, 0x9d, 0xa9, 0xed, 0xb5, 0xd6, 0x76, 0x44, 0xbe, 0x83, 0x78, 0x05, 0x5e, 0x8f, 0x37, 0x41,
+	0x33, 0xbb, 0xbe, 0xbb, 0x38, 0xe4, 0xdb, 0xce, 0xcc, 0xcf, 0xb3, 0x33, 0xf3, 0xfb, 0xed, 0xc8,
+	0xf0, 0xa4, 0xb6, 0xa6, 0x35, 0x67, 0xab, 0xdb, 0x56, 0xa7, 0x26, 0xd3, 0x2f, 0xc8, 0xe4, 0x71,
+	0x6f, 0xcb, 0x7f, 0xc6, 0x10, 0xfe, 0x6a, 0xb2, 0xae, 0xd0, 0x5c, 0x40, 0x54, 0x27, 0xe9, 0x87,
+	0x64, 0xa3, 0xc5, 0x78, 0x31, 0x3e, 0x9d, 0xa9, 0xde, 0xc4, 0x48, 0x73, 0x5b, 0xae, 0x4c, 0xd1,
+	0x88, 0xc9, 0x62, 0x8a, 0x11, 0x6f, 0xf2, 0xe7, 0x10, 0x60, 0x1a, 0x31, 0x5d, 0x4c, 0x4f, 0xe7,
+	0xe7, 0x9f, 0xbe, 0xd8, 0xdd, 0xf3, 0x5b, 0xad, 0x6d, 0xd2, 0xe6, 0xa6, 0x52, 0x04, 0xe0, 0x3f,
+	0xc2, 0x2c, 0xd3, 0xab, 0x6e, 0x73, 0x59, 0xad, 0x8d, 0x08, 0x16, 0xe3, 0xbb, 0xe8, 0x9f, 0xfa,
+	0x90, 0xda, 0xa3, 0xe4, 0xdf, 0x0c, 0x66, 0xbb, 0x34, 0xfc, 0x04, 0x82, 0xba, 0x6b, 0xb6, 0x54,
+	0xda, 0xfc, 0xfc, 0x68, 0xff, 0xed, 0x55, 0xd7, 0x6c, 0x97, 0x23, 0x45, 0x51, 0xfe, 0x3d, 0x44,
+	0xb5, 0xb6, 0x65, 0xd7, 0x6a, 0x31, 0x21, 0xe0, 0x27, 0x07, 0x40, 0x17, 0x58, 0x8e, 0x54, 0x8f,
+	0xe1, 0xdf, 0x42, 0x98, 0x9a, 0xb2, 0xcc, 0x5b, 0x31, 0x25, 0xf4, 0xe3, 0x3d, 0xfa, 0x15, 0xf9,
+	0x97, 0x23, 0xe5, 0x11, 0x88, 0xb5, 0x3a, 0x4d, 0x8a, 0x42, 0x04, 0x43, 0xac, 0x22, 0x3f, 0x62,
+	0x1d, 0x82, 0x3f, 0x07, 0xb6, 0xb1, 0xa6, 0xab, 0x05, 0x23, 0xe8, 0xa3, 0x3d, 0xf4, 0x67, 0x74,
+	0x2f, 0x47, 0xca, 0xc5, 0xb1, 0xde, 0xae, 0x72, 0xd0, 0x70, 0x58, 0xef, 0x3b, 0x17, 0xc0, 0x7a,
+	0x3d, 0x06, 0xf3, 0x16, 0xc9, 0x4a, 0x17, 0x22, 0x1a, 0xe6, 0x7d, 0x83, 0x6e, 0xcc, 0x4b, 0x71,
+	0x9c, 0xd6, 0xef, 0x5d, 0x59, 0x8b, 0x78, 0x38, 0xad, 0x5f, 0xba, 0x12, 0x33, 0x52, 0x14, 0x5b,
+	0x5a, 0xd9, 0xa4, 0x4a, 0xb7, 0x62, 0x36, 0x6c, 0xe9, 0x82, 0xfc, 0xd8, 0x92, 0x43, 0x60, 0x46,
+	0x6a, 0x1e, 0x86, 0x19, 0x5f, 0xb9, 0xd6, 0x29, 0xea, 0x86, 0xd4, 0x76, 0xb6, 0x12, 0xf3, 0xfb,
+	0x43, 0x42, 0xbf, 0x1b, 0x12, 0x9e, 0x68, 0xf8, 0x5b, 0x93, 0xa7, 0x5a, 0x7c, 0x74, 0x6f, 0xf8,
+	0xe4, 0xa7, 0xe1, 0xd3, 0x09, 0x6f, 0x5f, 0x27, 0x79, 0x21, 0x3e, 0x1e, 0xde, 0xfe, 0x3a, 0xc9,
+	0xe9, 0x76, 0x8c, 0xe2, 0x78, 0xd6, 0x56, 0x37, 0x5b, 0x71, 0x34, 0x1c, 0xcf, 0x6b, 0x74, 0xe3,
+	0x78, 0x28, 0x8e, 0xc0, 0xae, 0xca, 0xd7, 0xb7, 0xe2, 0xd1, 0x10, 0xf8, 0x0e, 0xdd, 0x08, 0xa4,
+	0xf8, 0x45, 0x00, 0x13, 0x53, 0xcb, 0x13, 0x08, 0x50, 0x65, 0xfc, 0x0b, 0x98, 0x39, 0xe1, 0x5f,
+	0x66, 0x7f, 0x90, 0x10, 0x99, 0xda, 0x3b, 0xe4, 0x19, 0x44, 0x5e, 0x62, 0xfc, 0x31, 0x4c, 0x6b,
+	0x53, 0x7b, 0x08, 0x1e, 0x39, 0xf7, 0xf2, 0xc5, 0xf7, 0xc3, 0x9c, 0x58, 0xe5, 0x97, 0xc0, 0x48,
+	0x0e, 0xfc, 0x09, 0xb0, 0xd4, 0x74, 0x55, 0xeb, 0x3f, 0x70, 0x86, 0xfc, 0x0a, 0x22, 0x2f, 0x81,
+	0x07, 0x00, 0x31, 0x84, 0x4e, 0xa5, 0xf2, 0x18, 0x42, 0xa7, 0x41, 0x44, 0xe6, 0x55, 0xa6, 0xfb,
+	0xf2, 0x9c, 0x21, 0x3f, 0x07, 0x46, 0x02, 0xc1, 0x32, 0xaa, 0xa4, 0xec, 0x1f, 0x38, 0x9d, 0xe5,
+	0x31, 0x04, 0xa8, 0x0a, 0xfe, 0x14, 0xc2, 0x36, 0xb1, 0x1b, 0xdd, 0xdf, 0xe2, 0x2d, 0xb9, 0x80,
+	0xd0, 0xa9, 0xe1, 0x41, 0xc4, 0x31, 0x04, 0xa8, 0x82, 0x07, 0xe3, 0x31, 0x96, 0x87, 0x9c, 0x63,
+	0x2e, 0xc7, 0xed, 0x83, 0xd8, 0x10, 0x02, 0xe4, 0x54, 0x46, 0xc0, 0x88, 0x34, 0x3c, 0x10, 0x29,
+	0xf2, 0x1a, 0x66, 0xbb, 0x3d, 0x81, 0x7d, 0xae, 0xf3, 0x42, 0x37, 0x62, 0x4c, 0x0b, 0xc9, 0x19,
+	0xfc, 0x07, 0x98, 0xd5, 0xa6, 0xc9, 0x71, 0x61, 0xb8, 0x55, 0x35, 0x3f, 0xe7, 0x07, 0x0b, 0xc0,
+	0x87, 0xd4, 0x1e, 0x24, 0xaf, 0x20, 0xee, 0xdd, 0xb8, 0xe6, 0x30, 0xcd, 0x9e, 0xdc, 0xde, 0xc4,
+	0xb1, 0x15, 0x79, 0xe5, 0x76, 0x0a, 0x53, 0x74, 0xc6, 0x06, 0x52, 0x53, 0x74, 0x65, 0x45, 0xbb,
+	0x83, 0x29, 0x6f, 0xc9, 0x7f, 0xc7, 0x10, 0x5f, 0x57, 0x49, 0xdd, 0x6c, 0x4d, 0xcb, 0x4f, 0x80,
+	0xb5, 0xda, 0x96, 0xae, 0xcc, 0x3b, 0xc2, 0x7d, 0xab, 0x6d, 0xa9, 0x5c, 0x10, 0x9b, 0x69, 0xda,
+	0x24, 0xfd, 0xe0, 0xd5, 0xe1, 0x0c, 0x14, 0x51, 0x61, 0x36, 0xb4, 0x5a, 0x99, 0xc2, 0x23, 0x3f,
+	0x82, 0x49, 0x9d, 0xd2, 0xfa, 0x61, 0x6a, 0x52, 0xa7, 0xa8, 0x47, 0x24, 0xfd, 0x9a, 0xbe, 0x65,
+	0x84, 0xdb, 0x3b, 0xf8, 0x19, 0x44, 0xee, 0xf5, 0x34, 0x22, 0xa4, 0xdb, 0x3f, 0x1b, 0x3e, 0xb0,
+	0x2b, 0x93, 0x57, 0xad, 0xea, 0x51, 0x58, 0x46, 0x6b, 0xf1, 0x95, 0x45, 0xae, 0x0c, 0x32, 0xb0,
+	0xf7, 0x9b, 0xc4, 0x36, 0xb4, 0x4a, 0x98, 0xa2, 0xb3, 0xbc, 0x85, 0x00, 0xeb, 0xe7, 0x02, 0x42,
+	0xa7, 0x7f, 0x37, 0x30, 0x7c, 0xb0, 0xce, 0xe6, 0xa7, 0x10, 0xb4, 0x56, 0xf7, 0x5b, 0xf8, 0x80,
+	0x84, 0xb7, 0x56, 0x6b, 0xfc, 0x16, 0x1f, 0x2d, 0x22, 0xf8, 0xd7, 0x30, 0xbd, 0x49, 0xac, 0x98,
+	0x0e, 0xd7, 0xdf, 0xfb, 0xc4, 0x7a, 0x1c, 0xc6, 0x2f, 0x42, 0x08, 0x70, 0x58, 0xf2, 0x1b, 0x88,
+	0xfb, 0x14, 0xfc, 0x19, 0xc4, 0xe9, 0x36, 0x2f, 0x32, 0xab, 0x2b, 0x1a, 0x30, 0x53, 0x3b, 0x5b,
+	0x5e, 0x42, 0xe4, 0x33, 0xe0, 0xd8, 0xf2, 0xcc, 0x53, 0x3a, 0xc9, 0x33, 0xec, 0x73, 0x65, 0xba,
+	0x2a, 0xa3, 0xe2, 0x62, 0xe5, 0x0c, 0x64, 0x7f, 0x95, 0x57, 0x59, 0x5e, 0x6d, 0x3c, 0xa1, 0xbd,
+	0x29, 0xff, 0x1a, 0xc3, 0xfc, 0x60, 0x60, 0x9e, 0x86, 0xf1, 0x8e, 0x86, 0xff, 0xa7, 0xef, 0x29,
+	0x84, 0x85, 0xd9, 0xbc, 0xd1, 0x3b, 0x7d, 0x38, 0xeb, 0x2e, 0x69, 0xc1, 0x90, 0xb4, 0x67, 0x10,
+	0xd3, 0xd8, 0xf1, 0x3b, 0x46, 0xdf, 0xed, 0x6c, 0xf9, 0xe7, 0x18, 0xd8, 0xfb, 0xa4, 0xe8, 0x34,
+	0x3f, 0xbe, 0xb7, 0x88, 0x96, 0xa3, 0x83, 0x55, 0x74, 0xc0, 0x0b, 0xb6, 0x38, 0x3b, 0xe0, 0xe5,
+	0xc4, 0xf3, 0x32, 0x1d, 0x2e, 0x52, 0x1c, 0xea, 0x8e, 0x13, 0xee, 0x38, 0x09, 0x7c, 0x66, 0x22,
+	0x20, 0x02, 0x76, 0x83, 0x97, 0xcb, 0x97, 0x10, 0x20, 0x98, 0x7f, 0x37, 0x98, 0xfe, 0x9d, 0x3d,
+	0x4a, 0x75, 0xee, 0xe9, 0x58, 0x85, 0xf4, 0xe3, 0xf1, 0xf2, 0xbf, 0x01, 0x00, 0x7e, 0x36, 0xed,
+	0xd2, 0x90, 0x08, 0x00, 0x00,
 }
@@ -0,0 +1,187 @@
+// Package repl evaluates operations on a runtime.Runtime interactively, one
+// line at a time.
+//
+// Each line holds one or more operations separated by semicolons, written in
+// the syntax of package asm:
+//
+//	push Z; push S
+//	roll 3
+//	commit
+//
+// Calls run the def or relation they name in the loaded module until it
+// returns; other control flow isn't allowed. A line may also hold:
+//
+//	undo            undo the last line
+//	reset           clear the stack, log and history
+//
+// Symbols pushed by name which aren't in the symbol table are added to it.
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hjfreyer/stalog/asm"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+)
+
+// maxSteps bounds the number of operations a call may evaluate.
+const maxSteps = 1000000
+
+// Session is an interactive session.
+type Session struct {
+	rt runtime.Runtime
+	// code is the code of the loaded module, and labels maps the names of
+	// its labels to their positions.
+	code   []*pb.Operation
+	labels map[string]int
+	// marks holds, for each line which can be undone, the position in the
+	// runtime's choice points of the one made before evaluating it.
+	marks []int
+}
+
+// New returns a session with an empty runtime.
+func New() *Session {
+	s := &Session{}
+	s.Load(&pb.Module{})
+	return s
+}
+
+// Load resets the session and loads mod's symbol table and code.
+func (s *Session) Load(mod *pb.Module) {
+	s.rt = runtime.Runtime{
+		Symbols:  append([]string(nil), mod.Symbols...),
+		MaxSteps: maxSteps,
+	}
+	s.code = mod.Code
+	s.labels = map[string]int{}
+	for pc, op := range mod.Code {
+		if l := op.GetLabel(); l != nil {
+			s.labels[l.Name] = pc
+		}
+	}
+	s.marks = nil
+}
+
+// Runtime returns the session's runtime.
+func (s *Session) Runtime() *runtime.Runtime {
+	return &s.rt
+}
+
+// Eval evaluates a line. If any operation on it fails, the session is left
+// as it was before the line.
+func (s *Session) Eval(ctx context.Context, line string) error {
+	switch strings.TrimSpace(line) {
+	case "":
+		return nil
+	case "undo":
+		if !s.Undo() {
+			return errors.New("nothing to undo")
+		}
+		return nil
+	case "reset":
+		s.rt.Stack, s.rt.Log, s.rt.CallStack, s.rt.Choices, s.rt.Trail = nil, nil, nil, nil, nil
+		s.marks = nil
+		return nil
+	}
+
+	var ops []func(context.Context) error
+	for _, src := range strings.Split(line, ";") {
+		op, err := s.parse(strings.Fields(src))
+		if err != nil {
+			return err
+		}
+		ops = append(ops, op)
+	}
+
+	mark := len(s.rt.Choices)
+	// Mark the state to return to with a choice point, so that undoing
+	// a line, or an operation failing, backtracks to it. It resumes at the
+	// end of the code, so that backtracking to it during a call ends the
+	// call.
+	s.rt.PC = len(s.code)
+	if err := s.rt.Eval(&pb.Operation{Op: &pb.Operation_Choice{Choice: &pb.Choice{Target: int32(len(s.code))}}}); err != nil {
+		return err
+	}
+	s.marks = append(s.marks, mark)
+	for _, op := range ops {
+		err := op(ctx)
+		if len(s.rt.Choices) <= mark {
+			// The operation failed and backtracked to the mark.
+			s.marks = s.marks[:len(s.marks)-1]
+			return runtime.Failed
+		}
+		if err != nil {
+			s.Undo()
+			return err
+		}
+	}
+	return nil
+}
+
+// Undo restores the session to its state before the last line which hasn't
+// been undone. It returns false if there is no such line.
+func (s *Session) Undo() bool {
+	if len(s.marks) == 0 {
+		return false
+	}
+	mark := s.marks[len(s.marks)-1]
+	s.marks = s.marks[:len(s.marks)-1]
+	s.rt.Choices = s.rt.Choices[:mark+1]
+	return s.rt.Backtrack()
+}
+
+// parse parses an operation.
+func (s *Session) parse(fields []string) (func(context.Context) error, error) {
+	op, err := asm.Parse(fields, s.symbol, s.label)
+	if err != nil {
+		return nil, err
+	}
+	switch o := op.GetOp().(type) {
+	case *pb.Operation_Call:
+		return func(ctx context.Context) error {
+			return s.call(ctx, int(o.Call.Target))
+		}, nil
+	case *pb.Operation_Label, *pb.Operation_Jump, *pb.Operation_Branch,
+		*pb.Operation_Return, *pb.Operation_Choice:
+		return nil, fmt.Errorf("can't use %s outside of a call", fields[0])
+	}
+	return func(context.Context) error {
+		return s.rt.Eval(op)
+	}, nil
+}
+
+// call runs the loaded module's code from target until it returns.
+func (s *Session) call(ctx context.Context, target int) error {
+	s.rt.CallStack = append(s.rt.CallStack, len(s.code))
+	s.rt.PC = target
+	return s.rt.Run(ctx, s.code)
+}
+
+// symbol returns the index of the symbol named name, adding it to the
+// symbol table if needed.
+func (s *Session) symbol(name string) (int32, error) {
+	for idx, sym := range s.rt.Symbols {
+		if sym == name {
+			return int32(idx), nil
+		}
+	}
+	if name == "" || name[0] < 'A' || 'Z' < name[0] {
+		return 0, fmt.Errorf("bad symbol name %q", name)
+	}
+	s.rt.Symbols = append(s.rt.Symbols, name)
+	return int32(len(s.rt.Symbols) - 1), nil
+}
+
+// label returns the position of the def or relation name in the loaded
+// module.
+func (s *Session) label(name string) (int32, error) {
+	target, ok := s.labels[name]
+	if !ok {
+		return 0, fmt.Errorf("no def %s in the loaded module", name)
+	}
+	return int32(target), nil
+}
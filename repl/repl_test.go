@@ -0,0 +1,126 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hjfreyer/stalog/compiler"
+	"github.com/hjfreyer/stalog/runtime"
+)
+
+type line struct {
+	src     string
+	stack   []string
+	log     []string
+	wantErr error
+}
+
+func run(t *testing.T, name string, s *Session, lines []line) {
+	for _, l := range lines {
+		err := s.Eval(context.Background(), l.src)
+		if l.wantErr != nil {
+			if err == nil || (l.wantErr != errAny && !errors.Is(err, l.wantErr)) {
+				t.Errorf("%s: %q: got error %v; wanted %v", name, l.src, err, l.wantErr)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %q: unexpected error: %v", name, l.src, err)
+		}
+		rt := s.Runtime()
+		var stack, log []string
+		for _, v := range rt.Stack {
+			stack = append(stack, rt.Format(v))
+		}
+		for _, v := range rt.Log {
+			log = append(log, rt.Format(v))
+		}
+		if !reflect.DeepEqual(stack, l.stack) || !reflect.DeepEqual(log, l.log) {
+			t.Errorf("%s: %q: got stack %v, log %v; wanted stack %v, log %v", name, l.src, stack, log, l.stack, l.log)
+		}
+	}
+}
+
+// errAny matches any error.
+var errAny = errors.New("any error")
+
+func TestEval(t *testing.T) {
+	var tcs = []struct {
+		name  string
+		lines []line
+	}{{
+		name: "stack ops",
+		lines: []line{
+			{src: "push A; push B", stack: []string{"A", "B"}},
+			{src: "push C", stack: []string{"A", "B", "C"}},
+			{src: "permute 3 0 2 1", stack: []string{"C", "A", "B"}},
+			{src: "swap", stack: []string{"C", "B", "A"}},
+			{src: "dup", stack: []string{"C", "B", "A", "A"}},
+			{src: "pop", stack: []string{"C", "B", "A"}},
+			{src: "commit", stack: []string{"C", "B"}, log: []string{"A"}},
+			{src: "recall 0; group 3", stack: []string{"(C B A)"}, log: []string{"A"}},
+			{src: "ungroup 3", stack: []string{"C", "B", "A"}, log: []string{"A"}},
+			{src: "roll 3", stack: []string{"A", "C", "B"}, log: []string{"A"}},
+		},
+	}, {
+		name: "undo",
+		lines: []line{
+			{src: "push A", stack: []string{"A"}},
+			{src: "push B; commit", stack: []string{"A"}, log: []string{"B"}},
+			{src: "undo", stack: []string{"A"}},
+			{src: "", stack: []string{"A"}},
+			{src: "undo"},
+			{src: "undo", wantErr: errAny},
+		},
+	}, {
+		name: "errors",
+		lines: []line{
+			{src: "push A", stack: []string{"A"}},
+			{src: "push B; permute 3", stack: []string{"A"}, wantErr: runtime.StackUnderflow},
+			{src: "bogus", stack: []string{"A"}, wantErr: errAny},
+			{src: "push a", stack: []string{"A"}, wantErr: errAny},
+			{src: "permute x", stack: []string{"A"}, wantErr: errAny},
+			{src: "call main", stack: []string{"A"}, wantErr: errAny},
+			{src: "jump 0", stack: []string{"A"}, wantErr: errAny},
+			{src: "undo"},
+		},
+	}, {
+		name: "unification",
+		lines: []line{
+			{src: "fresh; push A; group 2", stack: []string{"(_1 A)"}},
+			{src: "dup; push B; fresh; group 2; unify", stack: []string{"(B A)"}},
+			{src: "push C; fresh; group 2; unify", stack: []string{"(B A)"}, wantErr: runtime.Failed},
+			{src: "undo", stack: []string{"(_1 A)"}},
+			{src: "reset"},
+		},
+	}}
+
+	for _, tc := range tcs {
+		run(t, tc.name, New(), tc.lines)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	mod, err := compiler.Compile("", `package foo
+symbol Z
+symbol S
+def two = S S Z .
+nat(Z).
+nat(S(X)) :- nat(X).
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	s.Load(mod)
+	run(t, "load", s, []line{
+		{src: "call two", stack: []string{"S", "S", "Z"}},
+		{src: "group 2; group 2", stack: []string{"(S (S Z))"}},
+		{src: "dup; call nat", stack: []string{"(S (S Z))"}},
+		{src: "push S; call nat", stack: []string{"(S (S Z))"}, wantErr: runtime.Failed},
+		{src: "push Q", stack: []string{"(S (S Z))", "Q"}},
+		{src: "undo", stack: []string{"(S (S Z))"}},
+		{src: "undo", stack: []string{"(S (S Z))"}},
+		{src: "undo", stack: []string{"S", "S", "Z"}},
+	})
+}
@@ -0,0 +1,112 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// ChoicePoint is the state saved by a Choice operation.
+type ChoicePoint struct {
+	// PC is where execution resumes after backtracking.
+	PC        int
+	Stack     []Value
+	LogLen    int
+	CallStack []int
+	TrailLen  int
+}
+
+func (r *Runtime) choice(c *pb.Choice) error {
+	if !r.Verified && c.Target < 0 {
+		return BadTarget
+	}
+	r.Choices = append(r.Choices, ChoicePoint{
+		PC:        int(c.Target),
+		Stack:     append([]Value(nil), r.Stack...),
+		LogLen:    len(r.Log),
+		CallStack: append([]int(nil), r.CallStack...),
+		TrailLen:  len(r.Trail),
+	})
+	r.PC++
+	return nil
+}
+
+func (r *Runtime) fail(*pb.Fail) error {
+	if !r.Backtrack() {
+		return Failed
+	}
+	return nil
+}
+
+// Backtrack restores the runtime to its most recent choice point and
+// discards it. It returns false, leaving the runtime unchanged, if there are
+// no choice points.
+func (r *Runtime) Backtrack() bool {
+	if len(r.Choices) == 0 {
+		return false
+	}
+	cp := r.Choices[len(r.Choices)-1]
+	r.Choices = r.Choices[:len(r.Choices)-1]
+	// Copy rather than truncate in place, so that slices of earlier
+	// solutions held by callers aren't overwritten.
+	r.PC = cp.PC
+	r.Stack = append([]Value(nil), cp.Stack...)
+	r.Log = r.Log[:cp.LogLen:cp.LogLen]
+	r.CallStack = append([]int(nil), cp.CallStack...)
+	r.undo(cp.TrailLen)
+	return true
+}
+
+// Solutions iterates over the solutions of a program. Each time the program
+// runs to completion, the runtime's Stack and Log hold a solution; the next
+// solution is found by backtracking into the most recent choice point.
+//
+//	sols := rt.Solutions(program)
+//	for sols.Next(ctx) {
+//		// Inspect rt.Stack and rt.Log.
+//	}
+//	if err := sols.Err(); err != nil {
+//		...
+//	}
+type Solutions struct {
+	rt      *Runtime
+	program []*pb.Operation
+	started bool
+	done    bool
+	err     error
+}
+
+// Solutions returns an iterator over the solutions of program, starting from
+// the runtime's current state.
+func (r *Runtime) Solutions(program []*pb.Operation) *Solutions {
+	return &Solutions{rt: r, program: program}
+}
+
+// Next finds the next solution, returning false when there are no more or an
+// error occurred.
+func (s *Solutions) Next(ctx context.Context) bool {
+	if s.done {
+		return false
+	}
+	if s.started && !s.rt.Backtrack() {
+		s.done = true
+		return false
+	}
+	s.started = true
+	err := s.rt.Run(ctx, s.program)
+	if err == nil {
+		return true
+	}
+	s.done = true
+	if !errors.Is(err, Failed) {
+		s.err = err
+	}
+	return false
+}
+
+// Err returns the error, if any, that stopped iteration. Running out of
+// solutions is not an error.
+func (s *Solutions) Err() error {
+	return s.err
+}
@@ -0,0 +1,163 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+func Choice(target int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Choice{
+			Choice: &pb.Choice{Target: target},
+		},
+	}
+}
+
+var Fail = &pb.Operation{
+	Op: &pb.Operation_Fail{
+		Fail: &pb.Fail{},
+	},
+}
+
+type solution struct {
+	stack []Value
+	log   []Value
+}
+
+func TestSolutions(t *testing.T) {
+	symbols := []string{"A", "B", "C", "D", "E"}
+
+	var tcs = []struct {
+		name      string
+		program   []*pb.Operation
+		solutions []solution
+		err       error
+	}{
+		{
+			name:      "deterministic",
+			program:   []*pb.Operation{Push(0), Push(1)},
+			solutions: []solution{{stack: []Value{A, B}}},
+		}, {
+			name:    "fail",
+			program: []*pb.Operation{Push(0), Fail},
+		}, {
+			name: "two choices",
+			program: []*pb.Operation{
+				Choice(3),
+				Push(0),
+				Jump(4),
+				Push(1),
+				Choice(7),
+				Push(2),
+				Jump(8),
+				Push(3),
+			},
+			solutions: []solution{
+				{stack: []Value{A, C}},
+				{stack: []Value{A, D}},
+				{stack: []Value{B, C}},
+				{stack: []Value{B, D}},
+			},
+		}, {
+			name: "filter",
+			program: []*pb.Operation{
+				Choice(3),
+				Push(0),
+				Jump(4),
+				Push(1),
+				Dup,
+				Push(1),
+				Branch(8),
+				Fail,
+			},
+			solutions: []solution{{stack: []Value{B}}},
+		}, {
+			name: "log restored",
+			program: []*pb.Operation{
+				Push(4),
+				Commit,
+				Choice(5),
+				Push(0),
+				Jump(6),
+				Push(1),
+				Commit,
+			},
+			solutions: []solution{
+				{stack: []Value{}, log: []Value{E, A}},
+				{stack: []Value{}, log: []Value{E, B}},
+			},
+		}, {
+			name: "call stack restored",
+			program: []*pb.Operation{
+				Call(3),
+				Push(2),
+				Jump(8),
+				Choice(6),
+				Push(0),
+				Return,
+				Push(1),
+				Return,
+			},
+			solutions: []solution{
+				{stack: []Value{A, C}},
+				{stack: []Value{B, C}},
+			},
+		}, {
+			name: "error after solution",
+			program: []*pb.Operation{
+				Choice(2),
+				Jump(3),
+				Pop,
+			},
+			solutions: []solution{{}},
+			err:       StackUnderflow,
+		},
+	}
+
+	for _, tc := range tcs {
+		rt := Runtime{Symbols: symbols}
+		sols := rt.Solutions(tc.program)
+		var got []solution
+		for sols.Next(context.Background()) {
+			got = append(got, solution{stack: rt.Stack, log: rt.Log})
+		}
+		if len(got) != len(tc.solutions) {
+			t.Errorf("%s: got %d solutions; wanted %d", tc.name, len(got), len(tc.solutions))
+		}
+		for i := 0; i < len(got) && i < len(tc.solutions); i++ {
+			want := tc.solutions[i]
+			if !reflect.DeepEqual(want.stack, got[i].stack) {
+				t.Errorf("%s: solution %d had wrong stack. Got:\n%v; wanted:\n%v",
+					tc.name, i, got[i].stack, want.stack)
+			}
+			if want.log != nil && !reflect.DeepEqual(want.log, got[i].log) {
+				t.Errorf("%s: solution %d had wrong log. Got:\n%v; wanted:\n%v",
+					tc.name, i, got[i].log, want.log)
+			}
+		}
+		if tc.err == nil && sols.Err() != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, sols.Err())
+		}
+		if tc.err != nil && !errors.Is(sols.Err(), tc.err) {
+			t.Errorf("%s: got error %v; wanted %v", tc.name, sols.Err(), tc.err)
+		}
+		if sols.Next(context.Background()) {
+			t.Errorf("%s: Next succeeded after iteration ended", tc.name)
+		}
+	}
+}
+
+func TestFailWithoutChoice(t *testing.T) {
+	rt := Runtime{Symbols: []string{"A"}}
+	err := rt.Run(context.Background(), []*pb.Operation{Push(0), Fail})
+	if !errors.Is(err, Failed) {
+		t.Errorf("got error %v; wanted %v", err, Failed)
+	}
+	if !reflect.DeepEqual(rt.Stack, []Value{A}) {
+		t.Errorf("failure modified stack: %v", rt.Stack)
+	}
+}
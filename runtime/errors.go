@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"fmt"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// ErrorKind classifies the ways evaluating an Operation can fail. Each
+// ErrorKind is itself an error, so callers can test for one with errors.Is.
+type ErrorKind int
+
+const (
+	// UnknownOpcode means the Operation had no recognized op set.
+	UnknownOpcode ErrorKind = iota
+	// BadSymbol means a Push referenced a symbol index outside Symbols.
+	BadSymbol
+	// StackUnderflow means the operation needed more values than the stack
+	// held.
+	StackUnderflow
+	// PermuteIndexOutOfRange means a Permute pushed an index not less than
+	// its pop count.
+	PermuteIndexOutOfRange
+	// LogIndexOutOfRange means a Recall referenced an index outside Log.
+	LogIndexOutOfRange
+	// BadCount means a Group or Ungroup had a negative count.
+	BadCount
+	// NotATree means an Ungroup found something other than a Tree on top of
+	// the stack.
+	NotATree
+	// ArityMismatch means an Ungroup found a Tree with the wrong number of
+	// children.
+	ArityMismatch
+	// StepLimitExceeded means Run evaluated MaxSteps operations without
+	// finishing the program.
+	StepLimitExceeded
+	// BadTarget means a control flow operation targeted a position outside
+	// the program.
+	BadTarget
+	// NotASymbol means a Branch compared something other than two Symbols.
+	NotASymbol
+	// CallStackUnderflow means a Return was evaluated outside any Call.
+	CallStackUnderflow
+	// Failed means a Fail was evaluated with no choice points left, so the
+	// program has no more solutions.
+	Failed
+)
+
+var errorKindNames = [...]string{
+	"unknown opcode",
+	"bad symbol",
+	"stack underflow",
+	"permute index out of range",
+	"log index out of range",
+	"bad count",
+	"not a tree",
+	"arity mismatch",
+	"step limit exceeded",
+	"bad target",
+	"not a symbol",
+	"call stack underflow",
+	"failed",
+}
+
+func (k ErrorKind) String() string {
+	if 0 <= int(k) && int(k) < len(errorKindNames) {
+		return errorKindNames[k]
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+func (k ErrorKind) Error() string {
+	return k.String()
+}
+
+// EvalError describes a failure to evaluate an Operation. It unwraps to its
+// Kind.
+type EvalError struct {
+	Kind ErrorKind
+	// Op is the operation that failed.
+	Op *pb.Operation
+	// PC is the position of Op in the program being run.
+	PC int
+	// StackDepth is the size of the stack when Op failed.
+	StackDepth int
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("pc %d: %v (stack depth %d): %v", e.PC, e.Kind, e.StackDepth, e.Op)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Kind
+}
@@ -1,14 +1,13 @@
 package runtime
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"strings"
 
 	pb "github.com/hjfreyer/stalog/proto"
 )
 
-var Err = errors.New("misc error")
-
 type Value interface {
 	IsValue()
 }
@@ -21,22 +20,205 @@ type Tree struct {
 	Children []Value
 }
 
-func (*Tree) IsChild() {}
+func (*Tree) IsValue() {}
+
+// Var is a logic variable. An unbound Var has a nil Binding.
+type Var struct {
+	ID      int
+	Binding Value
+}
+
+func (*Var) IsValue() {}
 
 type Runtime struct {
 	Symbols []string
 	Stack   []Value
 	Log     []Value
+
+	// PC is the position in the running program of the operation being
+	// evaluated. It is reported in errors, and Eval advances it to the next
+	// operation to evaluate.
+	PC int
+
+	// CallStack holds the return addresses of the active Calls.
+	CallStack []int
+
+	// Choices holds the choice points that can be backtracked to, most
+	// recent last.
+	Choices []ChoicePoint
+
+	// Trail holds the variables bound since execution started, so their
+	// bindings can be undone when backtracking.
+	Trail []*Var
+
+	// OccursCheck makes unification fail rather than bind a variable to a
+	// tree containing it.
+	OccursCheck bool
+
+	// vars counts the variables created, to number new ones.
+	vars int
+
+	// MaxSteps, if positive, bounds the number of operations a single call to
+	// Run may evaluate.
+	MaxSteps int
+
+	// Verified skips the checks of stack depth, symbols, counts, permute
+	// indices and targets which package verify proves unneeded. It may only
+	// be set while running code accepted by verify, from its start. Code
+	// which isn't may corrupt the runtime or panic.
+	Verified bool
+
+	// Tracer, if set, is called before and after each operation is
+	// evaluated.
+	Tracer Tracer
+}
+
+// Tracer observes the operations a Runtime evaluates. Tracers may inspect
+// the runtime, but not modify it.
+type Tracer interface {
+	// Before is called before op, at position pc, is evaluated.
+	Before(r *Runtime, pc int, op *pb.Operation)
+	// After is called after op, at position pc, is evaluated, with the
+	// error evaluating it, if any. The runtime's PC is the position of the
+	// next operation to evaluate.
+	After(r *Runtime, pc int, op *pb.Operation, err error)
+}
+
+// Run evaluates program starting at PC until PC reaches the end of the
+// program, an operation fails, the step budget is exhausted or ctx is done.
+// On success PC is left at len(program); on failure it is left at the
+// operation that failed or was about to run.
+func (r *Runtime) Run(ctx context.Context, program []*pb.Operation) error {
+	for steps := 0; r.PC != len(program); steps++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Step reports a PC outside the program before the budget.
+		if 0 < r.MaxSteps && r.MaxSteps <= steps && 0 <= r.PC && r.PC < len(program) {
+			return &EvalError{
+				Kind:       StepLimitExceeded,
+				Op:         program[r.PC],
+				PC:         r.PC,
+				StackDepth: len(r.Stack),
+			}
+		}
+		if err := r.Step(program); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// Step evaluates the operation of program at PC. Unlike Eval, it checks
+// that PC is in program and, unless the runtime is Verified, that control
+// flow targets are too. On failure it returns an *EvalError and leaves the
+// runtime unchanged.
+func (r *Runtime) Step(program []*pb.Operation) error {
+	if r.PC < 0 || len(program) <= r.PC {
+		return &EvalError{
+			Kind:       BadTarget,
+			PC:         r.PC,
+			StackDepth: len(r.Stack),
+		}
+	}
+	op := program[r.PC]
+	// Targets past the end are caught before evaluating, so the runtime is
+	// left unchanged.
+	if t, ok := target(op); ok && !r.Verified && len(program) < int(t) {
+		return &EvalError{
+			Kind:       BadTarget,
+			Op:         op,
+			PC:         r.PC,
+			StackDepth: len(r.Stack),
+		}
+	}
+	return r.Eval(op)
+}
+
+// target returns the target of a control flow operation, or false if op
+// has none.
+func target(o *pb.Operation) (int32, bool) {
+	switch op := o.GetOp().(type) {
+	case *pb.Operation_Jump:
+		return op.Jump.Target, true
+	case *pb.Operation_Branch:
+		return op.Branch.Target, true
+	case *pb.Operation_Call:
+		return op.Call.Target, true
+	case *pb.Operation_Choice:
+		return op.Choice.Target, true
+	}
+	return 0, false
+}
+
+// Eval evaluates a single operation and advances PC. On failure it returns
+// an *EvalError and leaves the runtime unchanged.
 func (r *Runtime) Eval(o *pb.Operation) error {
+	if r.Tracer == nil {
+		return r.evalOp(o)
+	}
+	pc := r.PC
+	r.Tracer.Before(r, pc, o)
+	err := r.evalOp(o)
+	r.Tracer.After(r, pc, o, err)
+	return err
+}
+
+func (r *Runtime) evalOp(o *pb.Operation) error {
+	if err := r.eval(o); err != nil {
+		return &EvalError{
+			Kind:       err.(ErrorKind),
+			Op:         o,
+			PC:         r.PC,
+			StackDepth: len(r.Stack),
+		}
+	}
+	return nil
+}
+
+// eval evaluates o, returning an ErrorKind on failure.
+func (r *Runtime) eval(o *pb.Operation) error {
+	var err error
 	switch op := o.GetOp().(type) {
 	case *pb.Operation_Push:
-		return r.push(op.Push)
+		err = r.push(op.Push)
 	case *pb.Operation_Permute:
-		return r.permute(op.Permute)
+		err = r.permute(op.Permute)
+	case *pb.Operation_Commit:
+		err = r.commit(op.Commit)
+	case *pb.Operation_Recall:
+		err = r.recall(op.Recall)
+	case *pb.Operation_Group:
+		err = r.group(op.Group)
+	case *pb.Operation_Ungroup:
+		err = r.ungroup(op.Ungroup)
+	case *pb.Operation_Fresh:
+		err = r.fresh(op.Fresh)
+	case *pb.Operation_Label:
+		// Labels do nothing.
+	// Control flow operations set PC themselves.
+	case *pb.Operation_Jump:
+		return r.jump(op.Jump)
+	case *pb.Operation_Branch:
+		return r.branch(op.Branch)
+	case *pb.Operation_Call:
+		return r.call(op.Call)
+	case *pb.Operation_Return:
+		return r.ret(op.Return)
+	case *pb.Operation_Choice:
+		return r.choice(op.Choice)
+	case *pb.Operation_Fail:
+		return r.fail(op.Fail)
+	case *pb.Operation_Unify:
+		return r.unify(op.Unify)
+	default:
+		return UnknownOpcode
+	}
+	if err != nil {
+		return err
 	}
-	panic("bad opcode")
+	r.PC++
+	return nil
 }
 
 func (r *Runtime) get(idx int32) Value {
@@ -44,22 +226,29 @@ func (r *Runtime) get(idx int32) Value {
 }
 
 func (r *Runtime) push(p *pb.Push) error {
-	if len(r.Symbols) <= int(p.SymbolIdx) {
-		return Err
+	if !r.Verified && (p.SymbolIdx < 0 || len(r.Symbols) <= int(p.SymbolIdx)) {
+		return BadSymbol
 	}
 	r.Stack = append(r.Stack, Symbol(p.SymbolIdx))
 	return nil
 }
 
 func (r *Runtime) permute(p *pb.Permute) error {
-	if len(r.Stack) < int(p.Pop) {
-		return fmt.Errorf("Cannot permute top %d elements of stack with size %d", p.Pop, len(r.Stack))
+	if !r.Verified {
+		if p.Pop < 0 {
+			return BadCount
+		}
+		if len(r.Stack) < int(p.Pop) {
+			return StackUnderflow
+		}
+		for _, idx := range p.Push {
+			if idx < 0 || p.Pop <= idx {
+				return PermuteIndexOutOfRange
+			}
+		}
 	}
 	var pushes []Value
 	for _, idx := range p.Push {
-		if p.Pop <= idx {
-			return Err
-		}
 		pushes = append(pushes, r.get(idx))
 	}
 	// Pop off entries.
@@ -68,3 +257,128 @@ func (r *Runtime) permute(p *pb.Permute) error {
 	r.Stack = append(r.Stack, pushes...)
 	return nil
 }
+
+func (r *Runtime) commit(c *pb.Commit) error {
+	if !r.Verified && len(r.Stack) == 0 {
+		return StackUnderflow
+	}
+	r.Log = append(r.Log, r.get(0))
+	r.Stack = r.Stack[:len(r.Stack)-1]
+	return nil
+}
+
+func (r *Runtime) recall(c *pb.Recall) error {
+	if c.Index < 0 || len(r.Log) <= int(c.Index) {
+		return LogIndexOutOfRange
+	}
+	r.Stack = append(r.Stack, r.Log[c.Index])
+	return nil
+}
+
+func (r *Runtime) group(g *pb.Group) error {
+	if !r.Verified {
+		if g.Count < 0 {
+			return BadCount
+		}
+		if len(r.Stack) < int(g.Count) {
+			return StackUnderflow
+		}
+	}
+	children := make([]Value, g.Count)
+	copy(children, r.Stack[len(r.Stack)-int(g.Count):])
+	r.Stack = r.Stack[:len(r.Stack)-int(g.Count)]
+	r.Stack = append(r.Stack, &Tree{Children: children})
+	return nil
+}
+
+func (r *Runtime) ungroup(u *pb.Ungroup) error {
+	if !r.Verified {
+		if u.Count < 0 {
+			return BadCount
+		}
+		if len(r.Stack) == 0 {
+			return StackUnderflow
+		}
+	}
+	t, ok := Deref(r.get(0)).(*Tree)
+	if !ok {
+		return NotATree
+	}
+	if len(t.Children) != int(u.Count) {
+		return ArityMismatch
+	}
+	r.Stack = r.Stack[:len(r.Stack)-1]
+	r.Stack = append(r.Stack, t.Children...)
+	return nil
+}
+
+func (r *Runtime) jump(j *pb.Jump) error {
+	if !r.Verified && j.Target < 0 {
+		return BadTarget
+	}
+	r.PC = int(j.Target)
+	return nil
+}
+
+func (r *Runtime) branch(b *pb.Branch) error {
+	if !r.Verified {
+		if b.Target < 0 {
+			return BadTarget
+		}
+		if len(r.Stack) < 2 {
+			return StackUnderflow
+		}
+	}
+	x, ok := Deref(r.get(0)).(Symbol)
+	y, ok2 := Deref(r.get(1)).(Symbol)
+	if !ok || !ok2 {
+		return NotASymbol
+	}
+	r.Stack = r.Stack[:len(r.Stack)-2]
+	if x == y {
+		r.PC = int(b.Target)
+	} else {
+		r.PC++
+	}
+	return nil
+}
+
+func (r *Runtime) call(c *pb.Call) error {
+	if !r.Verified && c.Target < 0 {
+		return BadTarget
+	}
+	r.CallStack = append(r.CallStack, r.PC+1)
+	r.PC = int(c.Target)
+	return nil
+}
+
+func (r *Runtime) ret(*pb.Return) error {
+	if len(r.CallStack) == 0 {
+		return CallStackUnderflow
+	}
+	r.PC = r.CallStack[len(r.CallStack)-1]
+	r.CallStack = r.CallStack[:len(r.CallStack)-1]
+	return nil
+}
+
+// Format returns a human-readable form of v using the runtime's symbol names.
+// Trees are printed as parenthesized lists of their children and unbound
+// variables as _ followed by their ID.
+func (r *Runtime) Format(v Value) string {
+	switch v := Deref(v).(type) {
+	case Symbol:
+		if 0 <= int(v) && int(v) < len(r.Symbols) {
+			return r.Symbols[v]
+		}
+		return fmt.Sprintf("#%d", int(v))
+	case *Tree:
+		var children []string
+		for _, c := range v.Children {
+			children = append(children, r.Format(c))
+		}
+		return "(" + strings.Join(children, " ") + ")"
+	case *Var:
+		return fmt.Sprintf("_%d", v.ID)
+	}
+	return fmt.Sprint(v)
+}
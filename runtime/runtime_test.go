@@ -1,6 +1,9 @@
 package runtime
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -32,6 +35,74 @@ func Permute(pop int32, push ...int32) *pb.Operation {
 	}
 }
 
+var Commit = &pb.Operation{
+	Op: &pb.Operation_Commit{
+		Commit: &pb.Commit{},
+	},
+}
+
+func Recall(index int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Recall{
+			Recall: &pb.Recall{Index: index},
+		},
+	}
+}
+
+func Group(count int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Group{
+			Group: &pb.Group{Count: count},
+		},
+	}
+}
+
+func Ungroup(count int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Ungroup{
+			Ungroup: &pb.Ungroup{Count: count},
+		},
+	}
+}
+
+func Label(name string) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Label{
+			Label: &pb.Label{Name: name},
+		},
+	}
+}
+
+func Jump(target int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Jump{
+			Jump: &pb.Jump{Target: target},
+		},
+	}
+}
+
+func Branch(target int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Branch{
+			Branch: &pb.Branch{Target: target},
+		},
+	}
+}
+
+func Call(target int32) *pb.Operation {
+	return &pb.Operation{
+		Op: &pb.Operation_Call{
+			Call: &pb.Call{Target: target},
+		},
+	}
+}
+
+var Return = &pb.Operation{
+	Op: &pb.Operation_Return{
+		Return: &pb.Return{},
+	},
+}
+
 var Pop = Permute(1)
 var Swap = Permute(2, 0, 1)
 var Dup = Permute(1, 0, 0)
@@ -48,13 +119,15 @@ func TestSomeCases(t *testing.T) {
 	symbols := []string{"A", "B", "C", "D", "E"}
 
 	var tcs = []struct {
-		name      string
-		steps     []step
-		failingOp *pb.Operation
+		name        string
+		steps       []step
+		failingOp   *pb.Operation
+		failingKind ErrorKind
 	}{
 		{
-			name:      "push bad symbol",
-			failingOp: Push(10),
+			name:        "push bad symbol",
+			failingOp:   Push(10),
+			failingKind: BadSymbol,
 		}, {
 			name: "push good symbol",
 			steps: []step{
@@ -75,19 +148,23 @@ func TestSomeCases(t *testing.T) {
 				{op: Pop, stack: []Value{A}},
 				{op: Pop, stack: []Value{}},
 			},
-			failingOp: Pop,
+			failingOp:   Pop,
+			failingKind: StackUnderflow,
 		}, {
-			name:      "pop empty",
-			failingOp: Pop,
+			name:        "pop empty",
+			failingOp:   Pop,
+			failingKind: StackUnderflow,
 		}, {
-			name:      "swap empty",
-			failingOp: Swap,
+			name:        "swap empty",
+			failingOp:   Swap,
+			failingKind: StackUnderflow,
 		}, {
 			name: "swap single",
 			steps: []step{
 				{op: Push(0)},
 			},
-			failingOp: Swap,
+			failingOp:   Swap,
+			failingKind: StackUnderflow,
 		}, {
 			name: "swap two",
 			steps: []step{
@@ -145,11 +222,26 @@ func TestSomeCases(t *testing.T) {
 				{op: Push(2)},
 				{op: Push(3), stack: []Value{A, B, C, D}},
 			},
-			failingOp: Permute(3, 2, 3, 0),
+			failingOp:   Permute(3, 2, 3, 0),
+			failingKind: PermuteIndexOutOfRange,
+		}, {
+			name: "permute negative index",
+			steps: []step{
+				{op: Push(0)},
+			},
+			failingOp:   Permute(1, -1),
+			failingKind: PermuteIndexOutOfRange,
+		}, {
+			name: "permute negative pop",
+			steps: []step{
+				{op: Push(0)},
+			},
+			failingOp:   Permute(-1),
+			failingKind: BadCount,
 		}, {
 			name: "roll 3",
 			steps: []step{
-				{op: Push(0)},d
+				{op: Push(0)},
 				{op: Push(1)},
 				{op: Push(2)},
 				{op: Push(3), stack: []Value{A, B, C, D}},
@@ -162,6 +254,145 @@ func TestSomeCases(t *testing.T) {
 				{op: Permute(3, 1, 0, 2), stack: []Value{A, D, B, C}},
 				{op: Permute(3, 1, 0, 2), stack: []Value{A, B, C, D}},
 			},
+		}, {
+			name:        "commit empty",
+			failingOp:   Commit,
+			failingKind: StackUnderflow,
+		}, {
+			name: "commit",
+			steps: []step{
+				{op: Push(0), stack: []Value{A}},
+				{op: Commit, stack: []Value{}, log: []Value{A}},
+			},
+			failingOp:   Commit,
+			failingKind: StackUnderflow,
+		}, {
+			name: "commit order",
+			steps: []step{
+				{op: Push(0)},
+				{op: Push(1)},
+				{op: Push(2), stack: []Value{A, B, C}},
+				{op: Commit, stack: []Value{A, B}, log: []Value{C}},
+				{op: Commit, stack: []Value{A}, log: []Value{C, B}},
+			},
+		}, {
+			name:        "recall empty log",
+			failingOp:   Recall(0),
+			failingKind: LogIndexOutOfRange,
+		}, {
+			name: "recall",
+			steps: []step{
+				{op: Push(0)},
+				{op: Push(1), stack: []Value{A, B}},
+				{op: Commit, stack: []Value{A}, log: []Value{B}},
+				{op: Commit, stack: []Value{}, log: []Value{B, A}},
+				{op: Recall(0), stack: []Value{B}, log: []Value{B, A}},
+				{op: Recall(1), stack: []Value{B, A}, log: []Value{B, A}},
+				{op: Recall(0), stack: []Value{B, A, B}, log: []Value{B, A}},
+			},
+		}, {
+			name: "recall out of bounds",
+			steps: []step{
+				{op: Push(0)},
+				{op: Commit, log: []Value{A}},
+			},
+			failingOp:   Recall(1),
+			failingKind: LogIndexOutOfRange,
+		}, {
+			name: "recall negative",
+			steps: []step{
+				{op: Push(0)},
+				{op: Commit, log: []Value{A}},
+			},
+			failingOp:   Recall(-1),
+			failingKind: LogIndexOutOfRange,
+		}, {
+			name:        "group empty",
+			failingOp:   Group(1),
+			failingKind: StackUnderflow,
+		}, {
+			name: "group nothing",
+			steps: []step{
+				{op: Group(0), stack: []Value{&Tree{Children: []Value{}}}},
+			},
+		}, {
+			name: "group",
+			steps: []step{
+				{op: Push(0)},
+				{op: Push(1)},
+				{op: Push(2), stack: []Value{A, B, C}},
+				{op: Group(2), stack: []Value{A, &Tree{Children: []Value{B, C}}}},
+				{op: Group(2), stack: []Value{
+					&Tree{Children: []Value{A, &Tree{Children: []Value{B, C}}}},
+				}},
+			},
+			failingOp:   Group(2),
+			failingKind: StackUnderflow,
+		}, {
+			name: "group negative",
+			steps: []step{
+				{op: Push(0)},
+			},
+			failingOp:   Group(-1),
+			failingKind: BadCount,
+		}, {
+			name: "ungroup",
+			steps: []step{
+				{op: Push(0)},
+				{op: Push(1)},
+				{op: Push(2)},
+				{op: Group(3), stack: []Value{&Tree{Children: []Value{A, B, C}}}},
+				{op: Ungroup(3), stack: []Value{A, B, C}},
+			},
+		}, {
+			name: "ungroup nested",
+			steps: []step{
+				{op: Push(1)},
+				{op: Push(1)},
+				{op: Push(0)},
+				{op: Group(2)},
+				{op: Group(2), stack: []Value{
+					&Tree{Children: []Value{B, &Tree{Children: []Value{B, A}}}},
+				}},
+				{op: Ungroup(2), stack: []Value{B, &Tree{Children: []Value{B, A}}}},
+				{op: Ungroup(2), stack: []Value{B, B, A}},
+			},
+		}, {
+			name:        "ungroup empty",
+			failingOp:   Ungroup(0),
+			failingKind: StackUnderflow,
+		}, {
+			name: "ungroup symbol",
+			steps: []step{
+				{op: Push(0)},
+			},
+			failingOp:   Ungroup(1),
+			failingKind: NotATree,
+		}, {
+			name: "ungroup wrong count",
+			steps: []step{
+				{op: Push(0)},
+				{op: Push(1)},
+				{op: Group(2)},
+			},
+			failingOp:   Ungroup(3),
+			failingKind: ArityMismatch,
+		}, {
+			name: "ungroup negative",
+			steps: []step{
+				{op: Push(0)},
+				{op: Group(1)},
+			},
+			failingOp:   Ungroup(-1),
+			failingKind: BadCount,
+		}, {
+			name: "commit and recall tree",
+			steps: []step{
+				{op: Push(0)},
+				{op: Group(1)},
+				{op: Commit, stack: []Value{}, log: []Value{&Tree{Children: []Value{A}}}},
+				{op: Recall(0), stack: []Value{&Tree{Children: []Value{A}}}},
+			},
 		},
 	}
 
@@ -170,6 +401,7 @@ func TestSomeCases(t *testing.T) {
 			Symbols: symbols,
 		}
 		for sidx, s := range tc.steps {
+			rt.PC = sidx
 			if err := rt.Eval(s.op); err != nil {
 				t.Errorf("%s: step %d failed: %v", tc.name, sidx, s.op)
 			}
@@ -182,8 +414,248 @@ func TestSomeCases(t *testing.T) {
 					tc.name, sidx, rt.Log, s.log)
 			}
 		}
-		if tc.failingOp != nil && rt.Eval(tc.failingOp) == nil {
+		if tc.failingOp == nil {
+			continue
+		}
+		rt.PC = len(tc.steps)
+		err := rt.Eval(tc.failingOp)
+		if err == nil {
 			t.Errorf("%s: failingStep failed to fail", tc.name)
+			continue
+		}
+		if !errors.Is(err, tc.failingKind) {
+			t.Errorf("%s: failingStep failed with %v; wanted %v", tc.name, err, tc.failingKind)
+		}
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) {
+			t.Errorf("%s: failingStep returned %T; wanted *EvalError", tc.name, err)
+			continue
 		}
+		if evalErr.Op != tc.failingOp || evalErr.PC != len(tc.steps) || evalErr.StackDepth != len(rt.Stack) {
+			t.Errorf("%s: failingStep returned wrong details: %+v", tc.name, evalErr)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	rt := Runtime{Symbols: []string{"Z", "S"}}
+	var tcs = []struct {
+		v    Value
+		want string
+	}{
+		{Symbol(0), "Z"},
+		{Symbol(7), "#7"},
+		{&Tree{}, "()"},
+		{&Tree{Children: []Value{Symbol(1), &Tree{Children: []Value{Symbol(1), Symbol(0)}}}}, "(S (S Z))"},
+	}
+	for _, tc := range tcs {
+		if got := rt.Format(tc.v); got != tc.want {
+			t.Errorf("Format(%v) = %q; wanted %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	symbols := []string{"A", "B", "C", "D", "E"}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var tcs = []struct {
+		name     string
+		ctx      context.Context
+		maxSteps int
+		start    int
+		program  []*pb.Operation
+		stack    []Value
+		log      []Value
+		pc       int
+		// callStack and choices are the call stack and number of choice
+		// points left.
+		callStack []int
+		choices   int
+		err       error
+	}{
+		{
+			name: "empty",
+		}, {
+			name:    "straight line",
+			program: []*pb.Operation{Push(0), Push(1), Swap, Commit},
+			stack:   []Value{B},
+			log:     []Value{A},
+			pc:      4,
+		}, {
+			name:    "stops at failure",
+			program: []*pb.Operation{Push(0), Pop, Pop, Push(1)},
+			stack:   []Value{},
+			pc:      2,
+			err:     StackUnderflow,
+		}, {
+			name:     "within budget",
+			maxSteps: 2,
+			program:  []*pb.Operation{Push(0), Push(1)},
+			stack:    []Value{A, B},
+			pc:       2,
+		}, {
+			name:     "exceeds budget",
+			maxSteps: 2,
+			program:  []*pb.Operation{Push(0), Push(1), Push(2)},
+			stack:    []Value{A, B},
+			pc:       2,
+			err:      StepLimitExceeded,
+		}, {
+			name:    "start past end",
+			start:   3,
+			program: []*pb.Operation{Push(0)},
+			pc:      3,
+			err:     BadTarget,
+		}, {
+			name:    "jump",
+			program: []*pb.Operation{Jump(2), Push(0), Push(1)},
+			stack:   []Value{B},
+			pc:      3,
+		}, {
+			name:    "branch taken",
+			program: []*pb.Operation{Push(0), Push(0), Branch(4), Push(2), Push(1)},
+			stack:   []Value{B},
+			pc:      5,
+		}, {
+			name:    "branch not taken",
+			program: []*pb.Operation{Push(0), Push(1), Branch(4), Push(2), Push(1)},
+			stack:   []Value{C, B},
+			pc:      5,
+		}, {
+			name:    "branch on tree",
+			program: []*pb.Operation{Push(0), Group(1), Push(0), Branch(4)},
+			stack:   []Value{&Tree{Children: []Value{A}}, A},
+			pc:      3,
+			err:     NotASymbol,
+		}, {
+			name:    "return without call",
+			program: []*pb.Operation{Return},
+			pc:      0,
+			err:     CallStackUnderflow,
+		}, {
+			name:    "call and return",
+			program: []*pb.Operation{Call(3), Push(1), Jump(5), Push(0), Return},
+			stack:   []Value{A, B},
+			pc:      5,
+		}, {
+			name:      "call",
+			program:   []*pb.Operation{Call(2), Push(0)},
+			pc:        2,
+			callStack: []int{1},
+		}, {
+			name:    "jump past end",
+			program: []*pb.Operation{Push(0), Jump(9)},
+			stack:   []Value{A},
+			pc:      1,
+			err:     BadTarget,
+		}, {
+			name:    "jump negative",
+			program: []*pb.Operation{Push(0), Jump(-1)},
+			stack:   []Value{A},
+			pc:      1,
+			err:     BadTarget,
+		}, {
+			name:    "branch past end",
+			program: []*pb.Operation{Push(0), Push(0), Branch(9)},
+			stack:   []Value{A, A},
+			pc:      2,
+			err:     BadTarget,
+		}, {
+			name:    "call past end",
+			program: []*pb.Operation{Call(9)},
+			pc:      0,
+			err:     BadTarget,
+		}, {
+			name:    "call negative",
+			program: []*pb.Operation{Call(-1)},
+			pc:      0,
+			err:     BadTarget,
+		}, {
+			name:    "choice past end",
+			program: []*pb.Operation{Choice(9)},
+			pc:      0,
+			err:     BadTarget,
+		}, {
+			name:    "canceled",
+			ctx:     canceled,
+			program: []*pb.Operation{Push(0)},
+			pc:      0,
+			err:     context.Canceled,
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := tc.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		rt := Runtime{
+			Symbols:  symbols,
+			MaxSteps: tc.maxSteps,
+			PC:       tc.start,
+		}
+		err := rt.Run(ctx, tc.program)
+		if tc.err == nil && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if tc.err != nil && !errors.Is(err, tc.err) {
+			t.Errorf("%s: got error %v; wanted %v", tc.name, err, tc.err)
+		}
+		var evalErr *EvalError
+		if errors.As(err, &evalErr) && evalErr.PC != tc.pc {
+			t.Errorf("%s: error reported pc %d; wanted %d", tc.name, evalErr.PC, tc.pc)
+		}
+		if rt.PC != tc.pc {
+			t.Errorf("%s: ended at pc %d; wanted %d", tc.name, rt.PC, tc.pc)
+		}
+		if tc.stack != nil && !reflect.DeepEqual(tc.stack, rt.Stack) {
+			t.Errorf("%s: wrong stack. Got:\n%v; wanted:\n%v", tc.name, rt.Stack, tc.stack)
+		}
+		if tc.log != nil && !reflect.DeepEqual(tc.log, rt.Log) {
+			t.Errorf("%s: wrong log. Got:\n%v; wanted:\n%v", tc.name, rt.Log, tc.log)
+		}
+		if (len(rt.CallStack) != 0 || len(tc.callStack) != 0) && !reflect.DeepEqual(tc.callStack, rt.CallStack) {
+			t.Errorf("%s: wrong call stack %v; wanted %v", tc.name, rt.CallStack, tc.callStack)
+		}
+		if len(rt.Choices) != tc.choices {
+			t.Errorf("%s: got %d choice points; wanted %d", tc.name, len(rt.Choices), tc.choices)
+		}
+	}
+}
+
+// recorder is a Tracer recording the events it sees.
+type recorder struct {
+	events []string
+}
+
+func (rec *recorder) Before(r *Runtime, pc int, op *pb.Operation) {
+	rec.events = append(rec.events, fmt.Sprintf("before %d depth %d", pc, len(r.Stack)))
+}
+
+func (rec *recorder) After(r *Runtime, pc int, op *pb.Operation, err error) {
+	rec.events = append(rec.events, fmt.Sprintf("after %d depth %d next %d error %v", pc, len(r.Stack), r.PC, err != nil))
+}
+
+func TestTracer(t *testing.T) {
+	rec := &recorder{}
+	rt := Runtime{Symbols: []string{"A"}, Tracer: rec}
+	program := []*pb.Operation{Push(0), Jump(3), Push(0), Pop, Pop}
+	if err := rt.Run(context.Background(), program); !errors.Is(err, StackUnderflow) {
+		t.Errorf("got error %v; wanted %v", err, StackUnderflow)
+	}
+	want := []string{
+		"before 0 depth 0",
+		"after 0 depth 1 next 1 error false",
+		"before 1 depth 1",
+		"after 1 depth 1 next 3 error false",
+		"before 3 depth 1",
+		"after 3 depth 0 next 4 error false",
+		"before 4 depth 0",
+		"after 4 depth 0 next 4 error true",
+	}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("wrong events. Got:\n%v; wanted:\n%v", rec.events, want)
 	}
 }
@@ -0,0 +1,259 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Snapshot returns the state of the runtime: its stack, log, PC, call stack,
+// choice points, trail and count of variables. Its configuration, such as
+// Symbols and MaxSteps, isn't included.
+func (r *Runtime) Snapshot() *pb.Snapshot {
+	e := encoder{s: &pb.Snapshot{}, terms: map[Value]int32{}}
+	s := e.s
+	s.Stack = e.values(r.Stack)
+	s.Log = e.values(r.Log)
+	s.Pc = int32(r.PC)
+	s.CallStack = int32s(r.CallStack)
+	for _, cp := range r.Choices {
+		s.Choices = append(s.Choices, &pb.ChoicePoint{
+			Pc:        int32(cp.PC),
+			Stack:     e.values(cp.Stack),
+			LogLen:    int32(cp.LogLen),
+			CallStack: int32s(cp.CallStack),
+			TrailLen:  int32(cp.TrailLen),
+		})
+	}
+	for _, v := range r.Trail {
+		s.Trail = append(s.Trail, e.value(v))
+	}
+	s.Vars = int32(r.vars)
+	return s
+}
+
+// Restore sets the state of the runtime to s, as returned by Snapshot,
+// keeping its configuration. If s is malformed, Restore returns an error and
+// leaves the runtime unchanged. A Verified runtime may only restore
+// snapshots taken while running the code it runs.
+func (r *Runtime) Restore(s *pb.Snapshot) error {
+	d, err := newDecoder(s.Terms)
+	if err != nil {
+		return err
+	}
+	stack, err := d.values(s.Stack)
+	if err != nil {
+		return err
+	}
+	log, err := d.values(s.Log)
+	if err != nil {
+		return err
+	}
+	var trail []*Var
+	for _, idx := range s.Trail {
+		v, err := d.value(idx)
+		if err != nil {
+			return err
+		}
+		x, ok := v.(*Var)
+		if !ok {
+			return fmt.Errorf("trail holds term %d, which isn't a variable", idx)
+		}
+		trail = append(trail, x)
+	}
+	var choices []ChoicePoint
+	for _, cp := range s.Choices {
+		stack, err := d.values(cp.Stack)
+		if err != nil {
+			return err
+		}
+		if cp.LogLen < 0 || len(log) < int(cp.LogLen) {
+			return fmt.Errorf("choice point log length %d out of range", cp.LogLen)
+		}
+		if cp.TrailLen < 0 || len(trail) < int(cp.TrailLen) {
+			return fmt.Errorf("choice point trail length %d out of range", cp.TrailLen)
+		}
+		choices = append(choices, ChoicePoint{
+			PC:        int(cp.Pc),
+			Stack:     stack,
+			LogLen:    int(cp.LogLen),
+			CallStack: ints(cp.CallStack),
+			TrailLen:  int(cp.TrailLen),
+		})
+	}
+
+	r.Stack = stack
+	r.Log = log
+	r.PC = int(s.Pc)
+	r.CallStack = ints(s.CallStack)
+	r.Choices = choices
+	r.Trail = trail
+	r.vars = int(s.Vars)
+	return nil
+}
+
+// encoder adds values to a snapshot's terms, once each.
+type encoder struct {
+	s *pb.Snapshot
+	// terms maps the values added to their indices.
+	terms map[Value]int32
+}
+
+func (e *encoder) value(v Value) int32 {
+	if idx, ok := e.terms[v]; ok {
+		return idx
+	}
+	// The value is indexed before its children are added, as a variable
+	// may be bound to a tree containing it.
+	idx := int32(len(e.s.Terms))
+	t := &pb.Term{}
+	e.s.Terms = append(e.s.Terms, t)
+	e.terms[v] = idx
+	switch v := v.(type) {
+	case Symbol:
+		t.Term = &pb.Term_Symbol{Symbol: int32(v)}
+	case *Tree:
+		tree := &pb.TreeTerm{}
+		t.Term = &pb.Term_Tree{Tree: tree}
+		for _, c := range v.Children {
+			tree.Children = append(tree.Children, e.value(c))
+		}
+	case *Var:
+		x := &pb.VarTerm{Id: int32(v.ID)}
+		t.Term = &pb.Term_Var{Var: x}
+		if v.Binding != nil {
+			x.Bound = true
+			x.Binding = e.value(v.Binding)
+		}
+	default:
+		panic(fmt.Sprintf("unknown value %T", v))
+	}
+	return idx
+}
+
+func (e *encoder) values(vs []Value) []int32 {
+	var idxs []int32
+	for _, v := range vs {
+		idxs = append(idxs, e.value(v))
+	}
+	return idxs
+}
+
+// decoder looks up the values of a snapshot's terms.
+type decoder struct {
+	terms []Value
+}
+
+// newDecoder builds the values of terms, checking that their references
+// are in range and that they have no cycles the runtime couldn't make. A
+// tree may contain itself only through a variable's binding, and variables
+// may not be bound to each other in a cycle.
+func newDecoder(terms []*pb.Term) (*decoder, error) {
+	d := &decoder{terms: make([]Value, len(terms))}
+	// Values are made before they're linked together, as they may refer to
+	// each other in any order.
+	for i, t := range terms {
+		switch t := t.GetTerm().(type) {
+		case *pb.Term_Symbol:
+			d.terms[i] = Symbol(t.Symbol)
+		case *pb.Term_Tree:
+			d.terms[i] = &Tree{}
+		case *pb.Term_Var:
+			d.terms[i] = &Var{ID: int(t.Var.GetId())}
+		default:
+			return nil, fmt.Errorf("term %d is empty", i)
+		}
+	}
+	for i, t := range terms {
+		switch v := d.terms[i].(type) {
+		case *Tree:
+			children, err := d.values(t.GetTree().GetChildren())
+			if err != nil {
+				return nil, err
+			}
+			v.Children = children
+		case *Var:
+			if x := t.GetVar(); x.Bound {
+				binding, err := d.value(x.Binding)
+				if err != nil {
+					return nil, err
+				}
+				v.Binding = binding
+			}
+		}
+	}
+
+	done := map[*Var]bool{}
+	for _, v := range d.terms {
+		seen := map[*Var]bool{}
+		for x, ok := v.(*Var); ok && !done[x]; x, ok = x.Binding.(*Var) {
+			if seen[x] {
+				return nil, errors.New("variables bound in a cycle")
+			}
+			seen[x] = true
+		}
+		for x := range seen {
+			done[x] = true
+		}
+	}
+
+	// nested holds the trees being visited, mapped to false, and those
+	// visited, mapped to true.
+	nested := map[*Tree]bool{}
+	var acyclic func(t *Tree) bool
+	acyclic = func(t *Tree) bool {
+		if done, ok := nested[t]; ok {
+			return done
+		}
+		nested[t] = false
+		for _, c := range t.Children {
+			if c, ok := c.(*Tree); ok && !acyclic(c) {
+				return false
+			}
+		}
+		nested[t] = true
+		return true
+	}
+	for _, v := range d.terms {
+		if t, ok := v.(*Tree); ok && !acyclic(t) {
+			return nil, errors.New("trees nested in a cycle")
+		}
+	}
+	return d, nil
+}
+
+func (d *decoder) value(idx int32) (Value, error) {
+	if idx < 0 || len(d.terms) <= int(idx) {
+		return nil, fmt.Errorf("term %d out of range", idx)
+	}
+	return d.terms[idx], nil
+}
+
+func (d *decoder) values(idxs []int32) ([]Value, error) {
+	var vs []Value
+	for _, idx := range idxs {
+		v, err := d.value(idx)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+func int32s(ns []int) []int32 {
+	var out []int32
+	for _, n := range ns {
+		out = append(out, int32(n))
+	}
+	return out
+}
+
+func ints(ns []int32) []int {
+	var out []int
+	for _, n := range ns {
+		out = append(out, int(n))
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// solutionsFrom returns the resolved stacks of the solutions of program,
+// continuing from the runtime's state.
+func solutionsFrom(t *testing.T, rt *Runtime, program []*pb.Operation) [][]string {
+	sols := rt.Solutions(program)
+	var got [][]string
+	for sols.Next(context.Background()) {
+		var sol []string
+		for _, v := range rt.Stack {
+			sol = append(sol, rt.Format(Resolve(v)))
+		}
+		got = append(got, sol)
+	}
+	if err := sols.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return got
+}
+
+func TestSnapshot(t *testing.T) {
+	// X+Y=2, as in TestAdd.
+	program := []*pb.Operation{
+		Fresh,
+		Fresh,
+		Permute(2, 1, 0, 1, 0),
+		Push(1), Push(1), Push(0), Group(2), Group(2),
+	}
+	start := int32(len(program) + 2)
+	program = append(program, Call(start), Jump(start+int32(len(add(start)))))
+	program = append(program, add(start)...)
+	symbols := []string{"Z", "S"}
+
+	want := solutionsFrom(t, &Runtime{Symbols: symbols}, program)
+	if len(want) != 3 {
+		t.Fatalf("got %d solutions; wanted 3", len(want))
+	}
+
+	// Snapshot the runtime after each operation up to the first solution,
+	// and check the restored runtime finds the same solutions.
+	rt := Runtime{Symbols: symbols}
+	for steps := 0; rt.PC != len(program); steps++ {
+		b, err := proto.Marshal(rt.Snapshot())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s pb.Snapshot
+		if err := proto.Unmarshal(b, &s); err != nil {
+			t.Fatal(err)
+		}
+		restored := Runtime{Symbols: symbols}
+		if err := restored.Restore(&s); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", steps, err)
+		}
+		if got := solutionsFrom(t, &restored, program); !reflect.DeepEqual(got, want) {
+			t.Errorf("step %d: wrong solutions. Got:\n%v; wanted:\n%v", steps, got, want)
+		}
+		if err := rt.Eval(program[rt.PC]); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", steps, err)
+		}
+	}
+}
+
+func TestSnapshotSharing(t *testing.T) {
+	var rt Runtime
+	x, y := rt.NewVar(), rt.NewVar()
+	// x is bound to a tree containing itself.
+	rt.Unify(x, tree(A, x))
+	rt.Stack = []Value{x, y, y}
+
+	var restored Runtime
+	if err := restored.Restore(rt.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	stack := restored.Stack
+	if stack[1] != stack[2] {
+		t.Error("variable no longer shared")
+	}
+	x2 := stack[0].(*Var)
+	if x2.Binding.(*Tree).Children[1] != x2 {
+		t.Error("cycle not restored")
+	}
+	if len(restored.Trail) != 1 || restored.Trail[0] != x2 {
+		t.Errorf("wrong trail %v", restored.Trail)
+	}
+	if v := restored.NewVar(); v.ID != 3 {
+		t.Errorf("new variable has ID %d; wanted 3", v.ID)
+	}
+}
+
+func TestRestoreErrors(t *testing.T) {
+	sym := &pb.Term{Term: &pb.Term_Symbol{Symbol: 0}}
+	bound := func(id, binding int32) *pb.Term {
+		return &pb.Term{Term: &pb.Term_Var{Var: &pb.VarTerm{Id: id, Bound: true, Binding: binding}}}
+	}
+	var tcs = []struct {
+		s    *pb.Snapshot
+		want string
+	}{{
+		s:    &pb.Snapshot{Terms: []*pb.Term{{}}},
+		want: "term 0 is empty",
+	}, {
+		s:    &pb.Snapshot{Terms: []*pb.Term{sym}, Stack: []int32{1}},
+		want: "term 1 out of range",
+	}, {
+		s:    &pb.Snapshot{Terms: []*pb.Term{{Term: &pb.Term_Tree{Tree: &pb.TreeTerm{Children: []int32{-1}}}}}},
+		want: "term -1 out of range",
+	}, {
+		s:    &pb.Snapshot{Terms: []*pb.Term{bound(1, 1), bound(2, 0)}},
+		want: "variables bound in a cycle",
+	}, {
+		s:    &pb.Snapshot{Terms: []*pb.Term{{Term: &pb.Term_Tree{Tree: &pb.TreeTerm{Children: []int32{0}}}}}},
+		want: "trees nested in a cycle",
+	}, {
+		s: &pb.Snapshot{Terms: []*pb.Term{
+			{Term: &pb.Term_Tree{Tree: &pb.TreeTerm{Children: []int32{1}}}},
+			{Term: &pb.Term_Tree{Tree: &pb.TreeTerm{Children: []int32{2, 0}}}},
+			sym,
+		}},
+		want: "trees nested in a cycle",
+	}, {
+		s:    &pb.Snapshot{Terms: []*pb.Term{sym}, Trail: []int32{0}},
+		want: "trail holds term 0, which isn't a variable",
+	}, {
+		s:    &pb.Snapshot{Choices: []*pb.ChoicePoint{{LogLen: 1}}},
+		want: "choice point log length 1 out of range",
+	}}
+	for _, tc := range tcs {
+		rt := Runtime{Stack: []Value{A}}
+		err := rt.Restore(tc.s)
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%v: got error %v; wanted %s", tc.s, err, tc.want)
+		}
+		if !reflect.DeepEqual(rt.Stack, []Value{A}) {
+			t.Errorf("%v: runtime changed", tc.s)
+		}
+	}
+}
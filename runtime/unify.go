@@ -0,0 +1,129 @@
+package runtime
+
+import (
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Deref follows the bindings of v until it reaches a Symbol, a Tree or an
+// unbound Var.
+func Deref(v Value) Value {
+	for {
+		x, ok := v.(*Var)
+		if !ok || x.Binding == nil {
+			return v
+		}
+		v = x.Binding
+	}
+}
+
+// Resolve returns v with every bound variable, however deeply nested,
+// replaced by its binding.
+func Resolve(v Value) Value {
+	switch v := Deref(v).(type) {
+	case *Tree:
+		children := make([]Value, len(v.Children))
+		for i, c := range v.Children {
+			children[i] = Resolve(c)
+		}
+		return &Tree{Children: children}
+	default:
+		return v
+	}
+}
+
+// NewVar returns a new unbound variable.
+func (r *Runtime) NewVar() *Var {
+	r.vars++
+	return &Var{ID: r.vars}
+}
+
+// Unify unifies x and y, binding variables in either so they are equal.
+// Symbols unify if they are the same symbol, and Trees if they have the same
+// number of children and their children unify pairwise. On failure, any
+// bindings made are undone.
+func (r *Runtime) Unify(x, y Value) bool {
+	mark := len(r.Trail)
+	if !r.unifyValues(x, y) {
+		r.undo(mark)
+		return false
+	}
+	return true
+}
+
+func (r *Runtime) unifyValues(x, y Value) bool {
+	x, y = Deref(x), Deref(y)
+	if x == y {
+		return true
+	}
+	if v, ok := x.(*Var); ok {
+		return r.bind(v, y)
+	}
+	if v, ok := y.(*Var); ok {
+		return r.bind(v, x)
+	}
+	switch x := x.(type) {
+	case Symbol:
+		return false
+	case *Tree:
+		t, ok := y.(*Tree)
+		if !ok || len(x.Children) != len(t.Children) {
+			return false
+		}
+		for i := range x.Children {
+			if !r.unifyValues(x.Children[i], t.Children[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (r *Runtime) bind(v *Var, x Value) bool {
+	if r.OccursCheck && occurs(v, x) {
+		return false
+	}
+	v.Binding = x
+	r.Trail = append(r.Trail, v)
+	return true
+}
+
+// occurs reports whether v appears in x.
+func occurs(v *Var, x Value) bool {
+	switch x := Deref(x).(type) {
+	case *Var:
+		return x == v
+	case *Tree:
+		for _, c := range x.Children {
+			if occurs(v, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// undo unbinds the variables trailed since the trail had length mark.
+func (r *Runtime) undo(mark int) {
+	for _, v := range r.Trail[mark:] {
+		v.Binding = nil
+	}
+	r.Trail = r.Trail[:mark]
+}
+
+func (r *Runtime) fresh(*pb.Fresh) error {
+	r.Stack = append(r.Stack, r.NewVar())
+	return nil
+}
+
+func (r *Runtime) unify(*pb.Unify) error {
+	if !r.Verified && len(r.Stack) < 2 {
+		return StackUnderflow
+	}
+	if !r.Unify(r.get(0), r.get(1)) {
+		return r.fail(nil)
+	}
+	r.Stack = r.Stack[:len(r.Stack)-2]
+	r.PC++
+	return nil
+}
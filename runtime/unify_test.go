@@ -0,0 +1,263 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+var Fresh = &pb.Operation{
+	Op: &pb.Operation_Fresh{
+		Fresh: &pb.Fresh{},
+	},
+}
+
+var Unify = &pb.Operation{
+	Op: &pb.Operation_Unify{
+		Unify: &pb.Unify{},
+	},
+}
+
+func tree(children ...Value) *Tree {
+	return &Tree{Children: children}
+}
+
+func TestUnify(t *testing.T) {
+	var tcs = []struct {
+		name        string
+		x, y        func(vars []*Var) Value
+		occursCheck bool
+		unifies     bool
+		// want is the resolved value of x after unification.
+		want func(vars []*Var) Value
+	}{
+		{
+			name:    "same symbol",
+			x:       func([]*Var) Value { return A },
+			y:       func([]*Var) Value { return A },
+			unifies: true,
+			want:    func([]*Var) Value { return A },
+		}, {
+			name: "different symbols",
+			x:    func([]*Var) Value { return A },
+			y:    func([]*Var) Value { return B },
+		}, {
+			name: "symbol and tree",
+			x:    func([]*Var) Value { return A },
+			y:    func([]*Var) Value { return tree(A) },
+		}, {
+			name:    "var and symbol",
+			x:       func(v []*Var) Value { return v[0] },
+			y:       func([]*Var) Value { return B },
+			unifies: true,
+			want:    func([]*Var) Value { return B },
+		}, {
+			name:    "var and var",
+			x:       func(v []*Var) Value { return v[0] },
+			y:       func(v []*Var) Value { return v[1] },
+			unifies: true,
+			want:    func(v []*Var) Value { return v[1] },
+		}, {
+			name:    "var with itself",
+			x:       func(v []*Var) Value { return v[0] },
+			y:       func(v []*Var) Value { return v[0] },
+			unifies: true,
+			want:    func(v []*Var) Value { return v[0] },
+		}, {
+			name:    "trees",
+			x:       func(v []*Var) Value { return tree(A, v[0], tree(v[1])) },
+			y:       func(v []*Var) Value { return tree(v[1], B, tree(A)) },
+			unifies: true,
+			want:    func(v []*Var) Value { return tree(A, B, tree(A)) },
+		}, {
+			name: "trees of different arity",
+			x:    func(v []*Var) Value { return tree(A, v[0]) },
+			y:    func(v []*Var) Value { return tree(A, B, C) },
+		}, {
+			name: "conflicting bindings",
+			x:    func(v []*Var) Value { return tree(v[0], v[0]) },
+			y:    func(v []*Var) Value { return tree(A, B) },
+		}, {
+			name:    "cyclic without occurs check",
+			x:       func(v []*Var) Value { return v[0] },
+			y:       func(v []*Var) Value { return tree(A, v[0]) },
+			unifies: true,
+		}, {
+			name:        "cyclic with occurs check",
+			x:           func(v []*Var) Value { return v[0] },
+			y:           func(v []*Var) Value { return tree(A, v[0]) },
+			occursCheck: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		rt := Runtime{OccursCheck: tc.occursCheck}
+		vars := []*Var{rt.NewVar(), rt.NewVar()}
+		x, y := tc.x(vars), tc.y(vars)
+		if got := rt.Unify(x, y); got != tc.unifies {
+			t.Errorf("%s: Unify returned %v; wanted %v", tc.name, got, tc.unifies)
+			continue
+		}
+		if !tc.unifies {
+			for i, v := range vars {
+				if v.Binding != nil {
+					t.Errorf("%s: failed unification left var %d bound to %v", tc.name, i, v.Binding)
+				}
+			}
+			if len(rt.Trail) != 0 {
+				t.Errorf("%s: failed unification left trail %v", tc.name, rt.Trail)
+			}
+			continue
+		}
+		if tc.want == nil {
+			continue
+		}
+		if got, want := Resolve(x), tc.want(vars); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: x resolved to %v; wanted %v", tc.name, got, want)
+		}
+		if got, want := Resolve(y), tc.want(vars); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: y resolved to %v; wanted %v", tc.name, got, want)
+		}
+	}
+}
+
+func TestUnifyOp(t *testing.T) {
+	rt := Runtime{Symbols: []string{"A", "B"}}
+	program := []*pb.Operation{
+		Fresh,
+		Dup,
+		Push(1),
+		Unify,
+	}
+	if err := rt.Run(context.Background(), program); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.Stack) != 1 || Deref(rt.Stack[0]) != B {
+		t.Errorf("wrong stack: %v", rt.Stack)
+	}
+	if got := rt.Format(rt.Stack[0]); got != "B" {
+		t.Errorf("bound var formatted as %q", got)
+	}
+
+	rt.PC = 0
+	err := rt.Run(context.Background(), []*pb.Operation{Push(0), Unify})
+	if !errors.Is(err, Failed) {
+		t.Errorf("got error %v; wanted %v", err, Failed)
+	}
+	if len(rt.Stack) != 2 || Deref(rt.Stack[0]) != B || rt.Stack[1] != A {
+		t.Errorf("failed unification modified stack: %v", rt.Stack)
+	}
+}
+
+// add is the relation
+//
+//	add(Z, Y, Y).
+//	add(S(X), Y, S(R)) :- add(X, Y, R).
+//
+// over naturals encoded as Z = Symbol 0 and S(X) = Tree{S, X} where S is
+// Symbol 1. It expects its three arguments on the stack, and consumes them.
+func add(start int32) []*pb.Operation {
+	return []*pb.Operation{
+		Label("add"),
+		Choice(start + 7),
+		// add(Z, Y, Y).
+		Push(0),
+		Permute(4, 2, 1, 3, 0),
+		Unify,
+		Unify,
+		Return,
+		// add(S(X), Y, S(R)) :- add(X, Y, R).
+		Fresh,
+		Fresh,
+		Permute(5, 1, 3, 0, 4, 1, 2, 0),
+		Push(1),
+		Swap,
+		Group(2),
+		Unify,
+		Push(1),
+		Swap,
+		Group(2),
+		Unify,
+		Call(start),
+		Return,
+	}
+}
+
+func TestAdd(t *testing.T) {
+	rt := Runtime{Symbols: []string{"Z", "S"}}
+	var tcs = []struct {
+		name string
+		// query leaves the arguments to add on the stack, with copies of
+		// any variables below them.
+		query []*pb.Operation
+		// solutions lists the first few solutions of the query, as the
+		// resolved values of the copied variables.
+		solutions [][]string
+	}{
+		{
+			name: "1+1",
+			query: []*pb.Operation{
+				Fresh,
+				Push(1), Push(0), Group(2),
+				Dup,
+				Permute(3, 2, 1, 0, 2),
+			},
+			solutions: [][]string{{"(S (S Z))"}},
+		}, {
+			name: "X+1",
+			query: []*pb.Operation{
+				Fresh,
+				Fresh,
+				Permute(2, 1, 0, 1, 0),
+				Push(1), Push(0), Group(2),
+				Swap,
+			},
+			solutions: [][]string{
+				{"Z", "(S Z)"},
+				{"(S Z)", "(S (S Z))"},
+				{"(S (S Z))", "(S (S (S Z)))"},
+			},
+		}, {
+			name: "X+Y=2",
+			query: []*pb.Operation{
+				Fresh,
+				Fresh,
+				Permute(2, 1, 0, 1, 0),
+				Push(1), Push(1), Push(0), Group(2), Group(2),
+			},
+			solutions: [][]string{
+				{"Z", "(S (S Z))"},
+				{"(S Z)", "(S Z)"},
+				{"(S (S Z))", "Z"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		// The program is the query, a call to add, a jump past add, and add.
+		program := append([]*pb.Operation(nil), tc.query...)
+		start := int32(len(program) + 2)
+		program = append(program, Call(start), Jump(start+int32(len(add(start)))))
+		program = append(program, add(start)...)
+
+		rt := rt
+		sols := rt.Solutions(program)
+		var got [][]string
+		for len(got) < len(tc.solutions) && sols.Next(context.Background()) {
+			var sol []string
+			for _, v := range rt.Stack {
+				sol = append(sol, rt.Format(Resolve(v)))
+			}
+			got = append(got, sol)
+		}
+		if err := sols.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got, tc.solutions) {
+			t.Errorf("%s: wrong solutions. Got:\n%v; wanted:\n%v", tc.name, got, tc.solutions)
+		}
+	}
+}
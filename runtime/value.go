@@ -0,0 +1,200 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// EncodeValue returns v as a Value message, with its bound variables replaced
+// by their bindings. Symbols are given by name if the runtime's symbol table
+// has one for them, and by index otherwise. Values containing themselves
+// through a variable's binding can't be encoded.
+func (r *Runtime) EncodeValue(v Value) (*pb.Value, error) {
+	return r.encodeValue(v, map[*Var]bool{})
+}
+
+// encodeValue encodes v, given the variables whose bindings are being
+// encoded.
+func (r *Runtime) encodeValue(v Value, binding map[*Var]bool) (*pb.Value, error) {
+	if x, ok := v.(*Var); ok && x.Binding != nil {
+		if binding[x] {
+			return nil, errors.New("can't encode cyclic value")
+		}
+		binding[x] = true
+		defer delete(binding, x)
+		return r.encodeValue(x.Binding, binding)
+	}
+	switch v := v.(type) {
+	case Symbol:
+		if idx := int(v); 0 <= idx && idx < len(r.Symbols) && r.symbolIdx(r.Symbols[idx]) == idx {
+			return &pb.Value{Value: &pb.Value_Symbol{Symbol: r.Symbols[idx]}}, nil
+		}
+		return &pb.Value{Value: &pb.Value_SymbolIdx{SymbolIdx: int32(v)}}, nil
+	case *Tree:
+		tree := &pb.Tree{}
+		for _, c := range v.Children {
+			child, err := r.encodeValue(c, binding)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+		}
+		return &pb.Value{Value: &pb.Value_Tree{Tree: tree}}, nil
+	case *Var:
+		return &pb.Value{Value: &pb.Value_Var{Var: int32(v.ID)}}, nil
+	}
+	return nil, fmt.Errorf("can't encode %T", v)
+}
+
+// symbolIdx returns the index of the first symbol named name, or -1.
+func (r *Runtime) symbolIdx(name string) int {
+	for idx, sym := range r.Symbols {
+		if sym == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// DecodeValue returns the value of p. Symbols given by name are looked up in
+// the runtime's symbol table, and those given by index must be in it. Each variable of p becomes a new variable of
+// the runtime, with variables sharing an ID in p sharing one in the result.
+func (r *Runtime) DecodeValue(p *pb.Value) (Value, error) {
+	return r.decodeValue(p, map[int32]*Var{})
+}
+
+func (r *Runtime) decodeValue(p *pb.Value, vars map[int32]*Var) (Value, error) {
+	switch p := p.GetValue().(type) {
+	case *pb.Value_SymbolIdx:
+		if p.SymbolIdx < 0 || len(r.Symbols) <= int(p.SymbolIdx) {
+			return nil, fmt.Errorf("symbol index %d out of range", p.SymbolIdx)
+		}
+		return Symbol(p.SymbolIdx), nil
+	case *pb.Value_Symbol:
+		idx := r.symbolIdx(p.Symbol)
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown symbol %s", p.Symbol)
+		}
+		return Symbol(idx), nil
+	case *pb.Value_Tree:
+		tree := &Tree{}
+		for _, c := range p.Tree.GetChildren() {
+			child, err := r.decodeValue(c, vars)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+		}
+		return tree, nil
+	case *pb.Value_Var:
+		v, ok := vars[p.Var]
+		if !ok {
+			v = r.NewVar()
+			vars[p.Var] = v
+		}
+		return v, nil
+	}
+	return nil, errors.New("empty value")
+}
+
+// EncodeJSON returns v encoded as JSON. Symbols are written as their names
+// if known, like "S", and their indices otherwise, like 3. Trees are arrays
+// of their children, and unbound variables objects holding their IDs, like
+// {"var":2}. So S(Z, X) is written:
+//
+//	["S","Z",{"var":2}]
+func (r *Runtime) EncodeJSON(v Value) ([]byte, error) {
+	p, err := r.EncodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue(p))
+}
+
+// jsonValue returns p in the form EncodeJSON writes, for encoding/json.
+func jsonValue(p *pb.Value) interface{} {
+	switch p := p.GetValue().(type) {
+	case *pb.Value_SymbolIdx:
+		return p.SymbolIdx
+	case *pb.Value_Symbol:
+		return p.Symbol
+	case *pb.Value_Tree:
+		children := []interface{}{}
+		for _, c := range p.Tree.GetChildren() {
+			children = append(children, jsonValue(c))
+		}
+		return children
+	case *pb.Value_Var:
+		return map[string]int32{"var": p.Var}
+	}
+	return nil
+}
+
+// DecodeJSON returns the value of data, as written by EncodeJSON. Like
+// DecodeValue, it looks up symbols by name and makes new variables.
+func (r *Runtime) DecodeJSON(data []byte) (Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x interface{}
+	if err := dec.Decode(&x); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after value")
+	}
+	p, err := protoValue(x)
+	if err != nil {
+		return nil, err
+	}
+	return r.DecodeValue(p)
+}
+
+// protoValue returns the Value message for x, a value decoded by
+// encoding/json.
+func protoValue(x interface{}) (*pb.Value, error) {
+	switch x := x.(type) {
+	case string:
+		return &pb.Value{Value: &pb.Value_Symbol{Symbol: x}}, nil
+	case json.Number:
+		idx, err := index(x)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Value{Value: &pb.Value_SymbolIdx{SymbolIdx: idx}}, nil
+	case []interface{}:
+		tree := &pb.Tree{}
+		for _, c := range x {
+			child, err := protoValue(c)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+		}
+		return &pb.Value{Value: &pb.Value_Tree{Tree: tree}}, nil
+	case map[string]interface{}:
+		n, ok := x["var"].(json.Number)
+		if len(x) != 1 || !ok {
+			return nil, errors.New(`objects must be of the form {"var":id}`)
+		}
+		id, err := index(n)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Value{Value: &pb.Value_Var{Var: id}}, nil
+	}
+	return nil, fmt.Errorf("can't decode %v as a value", x)
+}
+
+// index returns n as an int32.
+func index(n json.Number) (int32, error) {
+	i, err := n.Int64()
+	if err != nil || int64(int32(i)) != i {
+		return 0, fmt.Errorf("bad index %s", n)
+	}
+	return int32(i), nil
+}
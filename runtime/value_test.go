@@ -0,0 +1,155 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+func TestEncodeValue(t *testing.T) {
+	rt := Runtime{Symbols: []string{"A", "B", "A"}}
+	x, y := rt.NewVar(), rt.NewVar()
+	rt.Unify(x, tree(B, A))
+	sym := func(name string) *pb.Value { return &pb.Value{Value: &pb.Value_Symbol{Symbol: name}} }
+	var tcs = []struct {
+		v    Value
+		want *pb.Value
+		json string
+	}{{
+		v:    A,
+		want: sym("A"),
+		json: `"A"`,
+	}, {
+		// The name of symbol 2 is that of symbol 0.
+		v:    C,
+		want: &pb.Value{Value: &pb.Value_SymbolIdx{SymbolIdx: 2}},
+		json: `2`,
+	}, {
+		v:    Symbol(7),
+		want: &pb.Value{Value: &pb.Value_SymbolIdx{SymbolIdx: 7}},
+		json: `7`,
+	}, {
+		v:    y,
+		want: &pb.Value{Value: &pb.Value_Var{Var: 2}},
+		json: `{"var":2}`,
+	}, {
+		v: tree(x, y, tree()),
+		want: &pb.Value{Value: &pb.Value_Tree{Tree: &pb.Tree{Children: []*pb.Value{
+			{Value: &pb.Value_Tree{Tree: &pb.Tree{Children: []*pb.Value{sym("B"), sym("A")}}}},
+			{Value: &pb.Value_Var{Var: 2}},
+			{Value: &pb.Value_Tree{Tree: &pb.Tree{}}},
+		}}}},
+		json: `[["B","A"],{"var":2},[]]`,
+	}}
+	for _, tc := range tcs {
+		name := rt.Format(tc.v)
+		got, err := rt.EncodeValue(tc.v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%s: wrong value. Got %v; wanted %v", name, got, tc.want)
+		}
+		json, err := rt.EncodeJSON(tc.v)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		} else if string(json) != tc.json {
+			t.Errorf("%s: wrong JSON. Got %s; wanted %s", name, json, tc.json)
+		}
+	}
+}
+
+func TestEncodeCyclicValue(t *testing.T) {
+	var rt Runtime
+	x := rt.NewVar()
+	rt.Unify(x, tree(A, x))
+	if _, err := rt.EncodeValue(x); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	// Symbol 2 is encoded by index, as its name is that of symbol 0.
+	rt := Runtime{Symbols: []string{"Z", "S", "Z"}}
+	x := rt.NewVar()
+	rt.Unify(x, Symbol(0))
+	v := tree(Symbol(1), tree(Symbol(1), x), Symbol(2), rt.NewVar())
+
+	// The value survives the trip through protos and JSON, though variables
+	// are renumbered.
+	p, err := rt.EncodeValue(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p2 pb.Value
+	if err := proto.Unmarshal(b, &p2); err != nil {
+		t.Fatal(err)
+	}
+	other := Runtime{Symbols: rt.Symbols}
+	got, err := other.DecodeValue(&p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := other.Format(got), "(S (S Z) Z _1)"; got != want {
+		t.Errorf("got %s; wanted %s", got, want)
+	}
+	if c := got.(*Tree).Children[2]; c != Symbol(2) {
+		t.Errorf("got symbol %v; wanted 2", c)
+	}
+	json, err := rt.EncodeJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = other.DecodeJSON(json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := other.Format(got), "(S (S Z) Z _2)"; got != want {
+		t.Errorf("got %s; wanted %s", got, want)
+	}
+	if c := got.(*Tree).Children[2]; c != Symbol(2) {
+		t.Errorf("got symbol %v; wanted 2", c)
+	}
+
+	// Variables with the same ID are the same variable.
+	got, err = other.DecodeJSON([]byte(`[{"var":7},{"var":7},{"var":8}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if children := got.(*Tree).Children; children[0] != children[1] || children[0] == children[2] {
+		t.Errorf("wrong variables in %s", other.Format(got))
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	rt := Runtime{Symbols: []string{"Z", "S"}}
+	var tcs = []struct {
+		json string
+		want string
+	}{
+		{`["S","T"]`, "unknown symbol T"},
+		{`1.5`, "bad index 1.5"},
+		{`{"var":1,"x":2}`, `objects must be of the form {"var":id}`},
+		{`true`, "can't decode true as a value"},
+		{`"S" "Z"`, "unexpected data after value"},
+		{`"S"]`, "unexpected data after value"},
+		{`"S"}`, "unexpected data after value"},
+		{`7`, "symbol index 7 out of range"},
+		{`-1`, "symbol index -1 out of range"},
+	}
+	for _, tc := range tcs {
+		_, err := rt.DecodeJSON([]byte(tc.json))
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s: got error %v; wanted %s", tc.json, err, tc.want)
+		}
+	}
+	if _, err := rt.DecodeValue(&pb.Value{}); err == nil || err.Error() != "empty value" {
+		t.Errorf("got error %v; wanted empty value", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Package srcmap maps the operations of compiled code back to the positions
+// in the source they were compiled from, using a module's debug info.
+package srcmap
+
+import (
+	"path/filepath"
+
+	"github.com/hjfreyer/stalog/ast"
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Map maps the operations of a module's code to source positions.
+type Map struct {
+	info *pb.DebugInfo
+}
+
+// New returns the map for mod. If mod has no debug info, no positions are
+// known.
+func New(mod *pb.Module) *Map {
+	return &Map{info: mod.GetDebugInfo()}
+}
+
+// Known reports whether any positions are known.
+func (m *Map) Known() bool {
+	return m != nil && len(m.info.GetPositions()) != 0
+}
+
+// Pos returns the source position of the operation at pc, or false if it
+// isn't known. Offsets aren't recorded, so the position's Offset is 0.
+func (m *Map) Pos(pc int) (ast.Pos, bool) {
+	if m == nil || pc < 0 || len(m.info.GetPositions()) <= pc {
+		return ast.Pos{}, false
+	}
+	p := m.info.Positions[pc]
+	if p.Line == 0 {
+		return ast.Pos{}, false
+	}
+	pos := ast.Pos{Line: int(p.Line), Column: int(p.Column)}
+	if 0 <= p.FileIdx && int(p.FileIdx) < len(m.info.Files) {
+		pos.Filename = m.info.Files[p.FileIdx]
+	}
+	return pos, true
+}
+
+// Line returns the position of the first operation compiled from the given
+// line of file, or false if there is none. The file matches a source file
+// with the same name, or the same base name.
+func (m *Map) Line(file string, line int) (int, bool) {
+	for pc := range m.info.GetPositions() {
+		pos, ok := m.Pos(pc)
+		if ok && pos.Line == line && (pos.Filename == file || filepath.Base(pos.Filename) == file) {
+			return pc, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,53 @@
+package srcmap
+
+import (
+	"testing"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+func TestMap(t *testing.T) {
+	m := New(&pb.Module{
+		DebugInfo: &pb.DebugInfo{
+			Files: []string{"dir/foo.slm", "bar.slm"},
+			Positions: []*pb.Position{
+				{FileIdx: 0, Line: 1, Column: 1},
+				{FileIdx: 0, Line: 3, Column: 5},
+				{},
+				{FileIdx: 1, Line: 3, Column: 2},
+				{FileIdx: 0, Line: 3, Column: 1},
+			},
+		},
+	})
+	if !m.Known() {
+		t.Error("no positions known")
+	}
+	for pc, want := range []string{"dir/foo.slm:1:1", "dir/foo.slm:3:5", "", "bar.slm:3:2", "dir/foo.slm:3:1", ""} {
+		pos, ok := m.Pos(pc)
+		if got := pos.String(); ok != (want != "") || ok && got != want {
+			t.Errorf("Pos(%d) = %v, %t; wanted %q", pc, pos, ok, want)
+		}
+	}
+
+	var tcs = []struct {
+		file string
+		line int
+		pc   int
+		ok   bool
+	}{
+		{"dir/foo.slm", 3, 1, true},
+		{"foo.slm", 3, 1, true},
+		{"bar.slm", 3, 3, true},
+		{"foo.slm", 2, 0, false},
+		{"baz.slm", 1, 0, false},
+	}
+	for _, tc := range tcs {
+		if pc, ok := m.Line(tc.file, tc.line); pc != tc.pc || ok != tc.ok {
+			t.Errorf("Line(%s, %d) = %d, %t; wanted %d, %t", tc.file, tc.line, pc, ok, tc.pc, tc.ok)
+		}
+	}
+
+	if New(&pb.Module{}).Known() {
+		t.Error("positions known without debug info")
+	}
+}
@@ -0,0 +1,130 @@
+// Package trace provides runtime.Tracers which log each operation a runtime
+// evaluates, for debugging code by hand or analyzing it offline.
+package trace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hjfreyer/stalog/asm"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+	"github.com/hjfreyer/stalog/srcmap"
+)
+
+// Text is a runtime.Tracer which writes a line to W for each operation
+// evaluated, showing its position, the operation in assembly syntax and the
+// stack and log after it. If the next operation isn't the following one, its
+// position is shown after an arrow, and the source position of the
+// operation, if known, at the end of the line. Errors get a line of their
+// own.
+//
+//	0  push Z               stack: [Z]  log: []
+//	1  call 5 -> 5          stack: [Z]  log: []  at foo.slm:3:1
+type Text struct {
+	W io.Writer
+	// Labels names the targets of control flow, as returned by asm.Labels.
+	// It may be nil.
+	Labels map[int32]string
+	// Source maps operations to source positions. It may be nil.
+	Source *srcmap.Map
+}
+
+// Before does nothing.
+func (t *Text) Before(r *runtime.Runtime, pc int, op *pb.Operation) {}
+
+// After writes the line for op.
+func (t *Text) After(r *runtime.Runtime, pc int, op *pb.Operation, err error) {
+	text := asm.Format(r.Symbols, t.Labels, op)
+	if err == nil && r.PC != pc+1 {
+		text += fmt.Sprintf(" -> %d", r.PC)
+	}
+	var at string
+	if pos, ok := t.Source.Pos(pc); ok {
+		at = fmt.Sprintf("  at %v", pos)
+	}
+	fmt.Fprintf(t.W, "%5d  %-20s stack: [%s]  log: [%s]%s\n", pc, text,
+		strings.Join(format(r, r.Stack), " "), strings.Join(format(r, r.Log), " "), at)
+	if err != nil {
+		fmt.Fprintf(t.W, "       error: %s\n", errorText(err))
+	}
+}
+
+// JSON is a runtime.Tracer which writes a JSON object to W for each
+// operation evaluated, one per line, like:
+//
+//	{"step":0,"pc":0,"op":"push Z","next":1,"stack":["Z"],"log":[]}
+//
+// Step counts the operations evaluated by the tracer, from 0. Op is the
+// operation in assembly syntax, and next the position of the operation to
+// evaluate next. Stack and log hold the values after the operation. Failed
+// operations have an error field describing the error, and operations with a
+// known source position a source field giving it.
+type JSON struct {
+	W io.Writer
+	// Labels names the targets of control flow, as returned by asm.Labels.
+	// It may be nil.
+	Labels map[int32]string
+	// Source maps operations to source positions. It may be nil.
+	Source *srcmap.Map
+
+	steps int
+}
+
+type event struct {
+	Step   int      `json:"step"`
+	PC     int      `json:"pc"`
+	Op     string   `json:"op"`
+	Next   int      `json:"next"`
+	Stack  []string `json:"stack"`
+	Log    []string `json:"log"`
+	Error  string   `json:"error,omitempty"`
+	Source string   `json:"source,omitempty"`
+}
+
+// Before does nothing.
+func (t *JSON) Before(r *runtime.Runtime, pc int, op *pb.Operation) {}
+
+// After writes the object for op.
+func (t *JSON) After(r *runtime.Runtime, pc int, op *pb.Operation, err error) {
+	e := event{
+		Step:  t.steps,
+		PC:    pc,
+		Op:    asm.Format(r.Symbols, t.Labels, op),
+		Next:  r.PC,
+		Stack: format(r, r.Stack),
+		Log:   format(r, r.Log),
+	}
+	if err != nil {
+		e.Error = errorText(err)
+	}
+	if pos, ok := t.Source.Pos(pc); ok {
+		e.Source = pos.String()
+	}
+	t.steps++
+	enc := json.NewEncoder(t.W)
+	enc.SetEscapeHTML(false)
+	enc.Encode(e)
+}
+
+// errorText describes err. The position and operation of an
+// *runtime.EvalError are left out, as they're already traced.
+func errorText(err error) string {
+	var e *runtime.EvalError
+	if errors.As(err, &e) {
+		return e.Kind.String()
+	}
+	return err.Error()
+}
+
+// format formats values with the runtime's symbol names.
+func format(r *runtime.Runtime, values []runtime.Value) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = r.Format(v)
+	}
+	return s
+}
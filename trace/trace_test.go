@@ -0,0 +1,87 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hjfreyer/stalog/asm"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+	"github.com/hjfreyer/stalog/srcmap"
+)
+
+const src = `package foo
+symbol Z
+symbol S
+file foo.slm
+	push Z
+	call succ
+	commit
+	pop
+	jump end
+pos foo.slm:3:1
+succ:
+pos foo.slm:3:9
+	push S
+	swap
+	group 2
+	return
+pos -
+end:
+`
+
+func run(t *testing.T, tracer func(mod *pb.Module) runtime.Tracer) {
+	mod, err := asm.Assemble("", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := runtime.Runtime{
+		Symbols: mod.Symbols,
+		Tracer:  tracer(mod),
+	}
+	if err := rt.Run(context.Background(), mod.Code); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestText(t *testing.T) {
+	var b bytes.Buffer
+	run(t, func(mod *pb.Module) runtime.Tracer {
+		return &Text{W: &b, Labels: asm.Labels(mod.Code), Source: srcmap.New(mod)}
+	})
+	want := `    0  push Z               stack: [Z]  log: []
+    1  call succ -> 5       stack: [Z]  log: []
+    5  succ:                stack: [Z]  log: []  at foo.slm:3:1
+    6  push S               stack: [Z S]  log: []  at foo.slm:3:9
+    7  swap                 stack: [S Z]  log: []  at foo.slm:3:9
+    8  group 2              stack: [(S Z)]  log: []  at foo.slm:3:9
+    9  return -> 2          stack: [(S Z)]  log: []  at foo.slm:3:9
+    2  commit               stack: []  log: [(S Z)]
+    3  pop                  stack: []  log: [(S Z)]
+       error: stack underflow
+`
+	if got := b.String(); got != want {
+		t.Errorf("wrong trace. Got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var b bytes.Buffer
+	run(t, func(mod *pb.Module) runtime.Tracer {
+		return &JSON{W: &b, Labels: asm.Labels(mod.Code), Source: srcmap.New(mod)}
+	})
+	want := `{"step":0,"pc":0,"op":"push Z","next":1,"stack":["Z"],"log":[]}
+{"step":1,"pc":1,"op":"call succ","next":5,"stack":["Z"],"log":[]}
+{"step":2,"pc":5,"op":"succ:","next":6,"stack":["Z"],"log":[],"source":"foo.slm:3:1"}
+{"step":3,"pc":6,"op":"push S","next":7,"stack":["Z","S"],"log":[],"source":"foo.slm:3:9"}
+{"step":4,"pc":7,"op":"swap","next":8,"stack":["S","Z"],"log":[],"source":"foo.slm:3:9"}
+{"step":5,"pc":8,"op":"group 2","next":9,"stack":["(S Z)"],"log":[],"source":"foo.slm:3:9"}
+{"step":6,"pc":9,"op":"return","next":2,"stack":["(S Z)"],"log":[],"source":"foo.slm:3:9"}
+{"step":7,"pc":2,"op":"commit","next":3,"stack":[],"log":["(S Z)"]}
+{"step":8,"pc":3,"op":"pop","next":3,"stack":[],"log":["(S Z)"],"error":"stack underflow"}
+`
+	if got := b.String(); got != want {
+		t.Errorf("wrong trace. Got:\n%s\nwanted:\n%s", got, want)
+	}
+}
@@ -0,0 +1,314 @@
+// Package verify statically checks that bytecode can't underflow the stack or
+// use bad symbol, permute or jump indices, so that the runtime can skip those
+// checks when running it.
+//
+// The verifier abstractly interprets the code, tracking only the depth of the
+// stack. Code runs from position 0 on a stack which may be empty, and every
+// position must be reached with the same depth on every path. The code from
+// each call target and label is a procedure, summarized by how many values
+// below its entry it uses and by how much it changes the stack depth when it
+// returns, which must be the same on every path. A call needs as many values
+// as its procedure uses, and continues after the call only if the procedure
+// can return.
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/hjfreyer/stalog/proto"
+)
+
+// Error is a problem found in code.
+type Error struct {
+	// PC is the position of the operation with the problem.
+	PC  int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("pc %d: %s", e.PC, e.Msg)
+}
+
+// ErrorList is a list of problems, ordered by position.
+type ErrorList []*Error
+
+// Error returns the messages of the errors, one per line.
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Module verifies mod's code against its symbol table. If the code has
+// problems, the error is an ErrorList.
+func Module(mod *pb.Module) error {
+	return Code(len(mod.Symbols), mod.Code)
+}
+
+// Code verifies code for a symbol table with the given number of symbols. If
+// the code has problems, the error is an ErrorList.
+func Code(symbols int, code []*pb.Operation) error {
+	v := &verifier{
+		symbols: symbols,
+		code:    code,
+		procs:   map[int]*summary{},
+		seen:    map[Error]bool{},
+	}
+	for pc, op := range code {
+		if msg := v.check(op); msg != "" {
+			v.report(pc, msg)
+		}
+	}
+	if len(v.errs) != 0 {
+		return v.errs
+	}
+
+	var entries []int
+	for pc, op := range code {
+		var target int32 = -1
+		switch op := op.GetOp().(type) {
+		case *pb.Operation_Call:
+			target = op.Call.Target
+		case *pb.Operation_Label:
+			target = int32(pc)
+		}
+		if 0 <= target && int(target) < len(code) && v.procs[int(target)] == nil {
+			v.procs[int(target)] = &summary{}
+			entries = append(entries, int(target))
+		}
+	}
+	sort.Ints(entries)
+
+	// Summaries only grow, so iterating reaches a fixed point unless
+	// a procedure's need is unbounded. Knowing which procedures return
+	// takes at most one round per procedure, and so does propagating
+	// needs through calls after that.
+	for round := 0; ; round++ {
+		changed := map[int]bool{}
+		for _, entry := range entries {
+			if s := v.analyze(entry, false, false); s != *v.procs[entry] {
+				*v.procs[entry] = s
+				changed[entry] = true
+			}
+		}
+		if len(changed) == 0 {
+			break
+		}
+		if 2*len(entries)+2 < round {
+			for _, entry := range entries {
+				if changed[entry] {
+					v.report(entry, "unbounded stack use by recursion")
+				}
+			}
+			return v.errs
+		}
+	}
+
+	v.analyze(0, true, true)
+	for _, entry := range entries {
+		v.analyze(entry, false, true)
+	}
+	if len(v.errs) != 0 {
+		sort.SliceStable(v.errs, func(i, j int) bool {
+			return v.errs[i].PC < v.errs[j].PC
+		})
+		return v.errs
+	}
+	return nil
+}
+
+// summary summarizes a procedure.
+type summary struct {
+	// need is the number of values below its entry the procedure uses.
+	need int
+	// returns is whether the procedure can return, and delta is how much
+	// it changes the depth of the stack if so.
+	returns bool
+	delta   int
+}
+
+type verifier struct {
+	symbols int
+	code    []*pb.Operation
+	// procs holds the summaries of the procedures, by entry.
+	procs map[int]*summary
+	errs  ErrorList
+	seen  map[Error]bool
+}
+
+func (v *verifier) report(pc int, msg string) {
+	if e := (Error{pc, msg}); !v.seen[e] {
+		v.seen[e] = true
+		v.errs = append(v.errs, &e)
+	}
+}
+
+// check checks op's arguments, returning a message describing the problem,
+// if any.
+func (v *verifier) check(o *pb.Operation) string {
+	target := func(t int32) string {
+		if t < 0 || len(v.code) < int(t) {
+			return fmt.Sprintf("target %d outside the code", t)
+		}
+		return ""
+	}
+	count := func(n int32) string {
+		if n < 0 {
+			return fmt.Sprintf("negative count %d", n)
+		}
+		return ""
+	}
+	switch op := o.GetOp().(type) {
+	case *pb.Operation_Push:
+		if op.Push.SymbolIdx < 0 || v.symbols <= int(op.Push.SymbolIdx) {
+			return fmt.Sprintf("symbol %d outside the symbol table", op.Push.SymbolIdx)
+		}
+	case *pb.Operation_Permute:
+		if msg := count(op.Permute.Pop); msg != "" {
+			return msg
+		}
+		for _, idx := range op.Permute.Push {
+			if idx < 0 || op.Permute.Pop <= idx {
+				return fmt.Sprintf("permute index %d out of range", idx)
+			}
+		}
+	case *pb.Operation_Recall:
+		if op.Recall.Index < 0 {
+			return fmt.Sprintf("negative log index %d", op.Recall.Index)
+		}
+	case *pb.Operation_Group:
+		return count(op.Group.Count)
+	case *pb.Operation_Ungroup:
+		return count(op.Ungroup.Count)
+	case *pb.Operation_Jump:
+		return target(op.Jump.Target)
+	case *pb.Operation_Branch:
+		return target(op.Branch.Target)
+	case *pb.Operation_Call:
+		return target(op.Call.Target)
+	case *pb.Operation_Choice:
+		return target(op.Choice.Target)
+	case *pb.Operation_Commit, *pb.Operation_Label, *pb.Operation_Return,
+		*pb.Operation_Fail, *pb.Operation_Fresh, *pb.Operation_Unify:
+	default:
+		return "unknown opcode"
+	}
+	return ""
+}
+
+// analyze interprets the code from entry, with depths relative to the depth
+// at entry, and returns its summary as a procedure. The code from position 0
+// is analyzed as the main code, which must not use values below its entry or
+// return. If report is set, problems are reported.
+func (v *verifier) analyze(entry int, main, report bool) summary {
+	var s summary
+	if entry == len(v.code) {
+		return s
+	}
+	depths := map[int]int{entry: 0}
+	work := []int{entry}
+	visit := func(from, pc, depth int) {
+		if pc == len(v.code) {
+			return
+		}
+		if d, ok := depths[pc]; !ok {
+			depths[pc] = depth
+			work = append(work, pc)
+		} else if d != depth && report {
+			v.report(from, fmt.Sprintf("reaches pc %d with stack depth %+d; elsewhere it is reached with %+d", pc, depth, d))
+		}
+	}
+	// use notes that the operation at pc uses n values of a stack of the
+	// given depth. It returns false if the main code underflows there,
+	// since execution can't continue.
+	use := func(pc, depth, n int) bool {
+		if main && depth < n {
+			if report {
+				v.report(pc, fmt.Sprintf("stack underflow: needs %d values, has %d", n, depth))
+			}
+			return false
+		}
+		if need := n - depth; s.need < need {
+			s.need = need
+		}
+		return true
+	}
+
+	for len(work) != 0 {
+		pc := work[len(work)-1]
+		work = work[:len(work)-1]
+		d := depths[pc]
+		switch op := v.code[pc].GetOp().(type) {
+		case *pb.Operation_Push, *pb.Operation_Recall, *pb.Operation_Fresh:
+			visit(pc, pc+1, d+1)
+		case *pb.Operation_Permute:
+			if !use(pc, d, int(op.Permute.Pop)) {
+				continue
+			}
+			visit(pc, pc+1, d-int(op.Permute.Pop)+len(op.Permute.Push))
+		case *pb.Operation_Commit:
+			if !use(pc, d, 1) {
+				continue
+			}
+			visit(pc, pc+1, d-1)
+		case *pb.Operation_Group:
+			if !use(pc, d, int(op.Group.Count)) {
+				continue
+			}
+			visit(pc, pc+1, d-int(op.Group.Count)+1)
+		case *pb.Operation_Ungroup:
+			if !use(pc, d, 1) {
+				continue
+			}
+			visit(pc, pc+1, d-1+int(op.Ungroup.Count))
+		case *pb.Operation_Label:
+			visit(pc, pc+1, d)
+		case *pb.Operation_Jump:
+			visit(pc, int(op.Jump.Target), d)
+		case *pb.Operation_Branch:
+			if !use(pc, d, 2) {
+				continue
+			}
+			visit(pc, int(op.Branch.Target), d-2)
+			visit(pc, pc+1, d-2)
+		case *pb.Operation_Call:
+			callee := v.procs[int(op.Call.Target)]
+			if callee == nil {
+				// A call to the end of the code ends it.
+				continue
+			}
+			if !use(pc, d, callee.need) {
+				continue
+			}
+			if callee.returns {
+				visit(pc, pc+1, d+callee.delta)
+			}
+		case *pb.Operation_Return:
+			if main {
+				if report {
+					v.report(pc, "return outside a call")
+				}
+				continue
+			}
+			if !s.returns {
+				s.returns, s.delta = true, d
+			} else if s.delta != d && report {
+				v.report(pc, fmt.Sprintf("returns with stack depth %+d; elsewhere the procedure at pc %d returns with %+d", d, entry, s.delta))
+			}
+		case *pb.Operation_Choice:
+			visit(pc, int(op.Choice.Target), d)
+			visit(pc, pc+1, d)
+		case *pb.Operation_Fail:
+		case *pb.Operation_Unify:
+			if !use(pc, d, 2) {
+				continue
+			}
+			visit(pc, pc+1, d-2)
+		}
+	}
+	return s
+}
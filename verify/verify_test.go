@@ -0,0 +1,325 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/hjfreyer/stalog/asm"
+	"github.com/hjfreyer/stalog/compiler"
+	pb "github.com/hjfreyer/stalog/proto"
+	"github.com/hjfreyer/stalog/runtime"
+)
+
+func TestCode(t *testing.T) {
+	var tcs = []struct {
+		name string
+		src  string
+		want string
+	}{{
+		name: "straight line",
+		src: `package foo
+symbol Z
+symbol S
+	push S
+	push Z
+	group 2
+	dup
+	commit
+	ungroup 2
+	roll 2
+	unify
+`,
+	}, {
+		name: "procedures",
+		src: `package foo
+symbol Z
+symbol S
+	push Z
+	call succ
+	call succ
+	call drop
+	jump end
+succ:
+	push S
+	swap
+	group 2
+	return
+drop:
+	pop
+	return
+end:
+`,
+	}, {
+		name: "recursion",
+		src: `package foo
+symbol Z
+symbol S
+	push Z
+	call nat
+	jump end
+nat:
+	choice more
+	return
+more:
+	push S
+	swap
+	group 2
+	call nat
+	return
+end:
+`,
+	}, {
+		name: "backtracking",
+		src: `package foo
+symbol A
+	choice 3
+	push A
+	jump 5
+	push A
+	push A
+	fail
+`,
+		want: "pc 4: reaches pc 5 with stack depth +2; elsewhere it is reached with +1",
+	}, {
+		name: "bad arguments",
+		src: `package foo
+symbol A
+	push 1
+	push -1
+	permute 2 0 2
+	pop -1
+	group -1
+	recall -1
+	jump 12
+	choice -1
+`,
+		want: `pc 0: symbol 1 outside the symbol table
+pc 1: symbol -1 outside the symbol table
+pc 2: permute index 2 out of range
+pc 3: negative count -1
+pc 4: negative count -1
+pc 5: negative log index -1
+pc 6: target 12 outside the code
+pc 7: target -1 outside the code`,
+	}, {
+		name: "underflow",
+		src: `package foo
+symbol A
+	choice 4
+	push A
+	group 2
+	fail
+	commit
+`,
+		want: `pc 2: stack underflow: needs 2 values, has 1
+pc 4: stack underflow: needs 1 values, has 0`,
+	}, {
+		name: "call needs arguments",
+		src: `package foo
+symbol A
+	push A
+	call f
+	jump end
+f:
+	pop 2
+	return
+end:
+`,
+		want: "pc 1: stack underflow: needs 2 values, has 1",
+	}, {
+		name: "inconsistent returns",
+		src: `package foo
+symbol A
+	call f
+	jump 7
+f:
+	choice 5
+	return
+	push A
+	return
+`,
+		want: "pc 6: returns with stack depth +1; elsewhere the procedure at pc 2 returns with +0",
+	}, {
+		name: "loop",
+		src: `package foo
+symbol A
+loop:
+	push A
+	jump loop
+`,
+		want: "pc 2: reaches pc 0 with stack depth +1; elsewhere it is reached with +0",
+	}, {
+		name: "return from main",
+		src: `package foo
+	return
+`,
+		want: "pc 0: return outside a call",
+	}, {
+		name: "unbounded recursion",
+		src: `package foo
+	call f
+	jump 5
+f:
+	pop
+	call f
+	return
+`,
+		want: "pc 2: unbounded stack use by recursion",
+	}}
+
+	for _, tc := range tcs {
+		mod, err := asm.Assemble("", tc.src)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		err = Module(mod)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected error %q", tc.name, tc.want)
+		} else if err.Error() != tc.want {
+			t.Errorf("%s: wrong error. Got:\n%v\nwanted:\n%s", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestCompiled(t *testing.T) {
+	src, err := ioutil.ReadFile("../examples/nat.slm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, main := range []string{
+		"def main = Z S S .",
+		"main :- nat(S(S(Z))).",
+		"main :- add(X, Y, S(S(Z))).",
+		"main :- add(X, S(Z), S(S(Z))), nat(X), add(X, X, S(S(Z))).",
+	} {
+		mod, err := compiler.Compile("nat.slm", string(src)+main)
+		if err != nil {
+			t.Errorf("%s: %v", main, err)
+			continue
+		}
+		if err := Module(mod); err != nil {
+			t.Errorf("%s: unexpected error: %v", main, err)
+			continue
+		}
+		checked, err := solutions(mod, false)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", main, err)
+		}
+		verified, err := solutions(mod, true)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", main, err)
+		}
+		if !reflect.DeepEqual(checked, verified) {
+			t.Errorf("%s: verified runtime found %v; wanted %v", main, verified, checked)
+		}
+	}
+}
+
+// solutions returns the stack and log of each solution of mod, formatted.
+func solutions(mod *pb.Module, verified bool) ([]string, error) {
+	rt := runtime.Runtime{
+		Symbols:  mod.Symbols,
+		MaxSteps: 10000,
+		Verified: verified,
+	}
+	var got []string
+	sols := rt.Solutions(mod.Code)
+	for sols.Next(context.Background()) {
+		var s string
+		for _, v := range rt.Stack {
+			s += rt.Format(v) + " "
+		}
+		s += "|"
+		for _, v := range rt.Log {
+			s += " " + rt.Format(v)
+		}
+		got = append(got, s)
+	}
+	return got, sols.Err()
+}
+
+// TestRandom checks that random code the verifier accepts never fails the
+// runtime checks it makes unneeded.
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	accepted := 0
+	for i := 0; i < 20000; i++ {
+		code := randomCode(r)
+		if Code(2, code) != nil {
+			continue
+		}
+		accepted++
+		rt := runtime.Runtime{
+			Symbols:  []string{"A", "B"},
+			MaxSteps: 100,
+		}
+		sols := rt.Solutions(code)
+		for n := 0; n < 5 && sols.Next(context.Background()); n++ {
+		}
+		var e *runtime.EvalError
+		if err := sols.Err(); errors.As(err, &e) {
+			switch e.Kind {
+			case runtime.StepLimitExceeded, runtime.LogIndexOutOfRange, runtime.NotATree,
+				runtime.ArityMismatch, runtime.NotASymbol:
+			default:
+				t.Errorf("verified code failed: %v\n%s", err, asm.Disassemble(&pb.Module{Symbols: rt.Symbols, Code: code}))
+			}
+		}
+	}
+	if accepted < 1000 {
+		t.Errorf("only %d random programs verified", accepted)
+	}
+}
+
+func randomCode(r *rand.Rand) []*pb.Operation {
+	n := 1 + r.Intn(8)
+	target := func() int32 { return int32(r.Intn(n + 1)) }
+	var code []*pb.Operation
+	for i := 0; i < n; i++ {
+		op := &pb.Operation{}
+		switch r.Intn(14) {
+		case 0, 1:
+			op.Op = &pb.Operation_Push{Push: &pb.Push{SymbolIdx: int32(r.Intn(2))}}
+		case 2, 3:
+			pop := int32(r.Intn(3))
+			p := &pb.Permute{Pop: pop}
+			for j := r.Intn(3); 0 < pop && 0 < j; j-- {
+				p.Push = append(p.Push, int32(r.Intn(int(pop))))
+			}
+			op.Op = &pb.Operation_Permute{Permute: p}
+		case 4:
+			op.Op = &pb.Operation_Commit{Commit: &pb.Commit{}}
+		case 5:
+			op.Op = &pb.Operation_Group{Group: &pb.Group{Count: int32(r.Intn(3))}}
+		case 6:
+			op.Op = &pb.Operation_Ungroup{Ungroup: &pb.Ungroup{Count: int32(r.Intn(3))}}
+		case 7:
+			op.Op = &pb.Operation_Jump{Jump: &pb.Jump{Target: target()}}
+		case 8:
+			op.Op = &pb.Operation_Branch{Branch: &pb.Branch{Target: target()}}
+		case 9:
+			op.Op = &pb.Operation_Call{Call: &pb.Call{Target: target()}}
+		case 10:
+			op.Op = &pb.Operation_Return{Return: &pb.Return{}}
+		case 11:
+			op.Op = &pb.Operation_Choice{Choice: &pb.Choice{Target: target()}}
+		case 12:
+			op.Op = &pb.Operation_Fresh{Fresh: &pb.Fresh{}}
+		case 13:
+			op.Op = &pb.Operation_Unify{Unify: &pb.Unify{}}
+		}
+		code = append(code, op)
+	}
+	return code
+}